@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// apacheTimeFormat is the timestamp layout of the Apache log formats
+const apacheTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// apacheFormatter is a logrus formatter emitting entries as access log lines
+// in the Apache Combined Log Format, followed by the request duration
+type apacheFormatter struct{}
+
+// Format renders a single log entry as a Combined Log Format line built
+// from the remote, method, path, proto, status, bytes, referer, user_agent
+// and duration fields, any of which may be missing and are then shown as -.
+// The message and all other fields are not part of the format and dropped.
+func (f *apacheFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var b bytes.Buffer
+
+	field := func(name string) string {
+		val, ok := entry.Data[name]
+		if !ok || val == nil {
+			return "-"
+		}
+
+		s := fmt.Sprint(val)
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+
+	quoted := func(name string) string {
+		s := field(name)
+		if s == "-" {
+			return `"-"`
+		}
+		return strconv.Quote(s)
+	}
+
+	request := "-"
+	if _, ok := entry.Data["method"]; ok {
+		request = field("method") + " " + field("path")
+		if proto := field("proto"); proto != "-" {
+			request += " " + proto
+		}
+	}
+
+	fmt.Fprintf(
+		&b,
+		"%s - - [%s] %s %s %s %s %s %s\n",
+		field("remote"),
+		entry.Time.Format(apacheTimeFormat),
+		strconv.Quote(request),
+		field("status"),
+		field("bytes"),
+		quoted("referer"),
+		quoted("user_agent"),
+		field("duration"),
+	)
+	return b.Bytes(), nil
+}
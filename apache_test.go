@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestApacheFormat(t *testing.T) {
+	at := time.Date(2020, time.October, 10, 13, 55, 36, 0, time.FixedZone("", -7*60*60))
+
+	tests := []struct {
+		name string
+		data logrus.Fields
+		want string
+	}{
+		{
+			"full",
+			logrus.Fields{
+				"remote":     "127.0.0.1",
+				"method":     "GET",
+				"path":       "/apache_pb.gif",
+				"proto":      "HTTP/1.0",
+				"status":     200,
+				"bytes":      2326,
+				"referer":    "http://www.example.com/start.html",
+				"user_agent": "Mozilla/4.08",
+				"duration":   "1.5ms",
+				"ignored":    "x",
+			},
+			`127.0.0.1 - - [10/Oct/2020:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08" 1.5ms` + "\n",
+		},
+		{
+			"missing",
+			logrus.Fields{"method": "POST", "path": "/login", "status": 401},
+			`- - - [10/Oct/2020:13:55:36 -0700] "POST /login" 401 - "-" "-" -` + "\n",
+		},
+		{
+			"empty",
+			logrus.Fields{},
+			`- - - [10/Oct/2020:13:55:36 -0700] "-" - - "-" "-" -` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		entry := &logrus.Entry{Data: tt.data, Time: at, Message: "served"}
+		got, err := (&apacheFormatter{}).Format(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got  %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// defaultBufferSize is the number of lines buffered for
+// asynchronous output if Config.BufferSize is not set
+const defaultBufferSize = 1024
+
+// errAsyncClosed is returned on writing to a closed asyncWriter
+var errAsyncClosed = errors.New("logging: write to closed logger")
+
+// asyncWriter hands each formatted line over to a background goroutine,
+// which writes it to the underlying writer, so that logging does not
+// block on slow outputs unless the buffer is full
+type asyncWriter struct {
+	w      io.Writer
+	lines  chan []byte
+	done   chan struct{}
+	budget *bufferBudget
+
+	mu     sync.RWMutex
+	closed bool
+
+	// queued and written count the lines, so that Flush
+	// can wait for those queued before it to be written
+	progress sync.Mutex
+	flushed  *sync.Cond
+	queued   uint64
+	written  uint64
+}
+
+// newAsyncWriter returns a writer buffering up to size lines for w,
+// holding no more memory than the given budget allows
+func newAsyncWriter(w io.Writer, size int, budget *bufferBudget) *asyncWriter {
+	if size <= 0 {
+		size = defaultBufferSize
+	}
+
+	a := &asyncWriter{
+		w:      w,
+		lines:  make(chan []byte, size),
+		done:   make(chan struct{}),
+		budget: budget,
+	}
+	a.flushed = sync.NewCond(&a.progress)
+	go a.run()
+	return a
+}
+
+// Write queues a copy of the line for writing. Errors raised by the
+// underlying writer are reported to stderr, as they happen later on.
+// If the line would take the memory buffered over the budget, the oldest
+// queued lines are dropped to make room for it, or the line itself if
+// there are none left to drop.
+func (a *asyncWriter) Write(p []byte) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return 0, errAsyncClosed
+	}
+
+	for !a.budget.reserve(len(p)) {
+		if !a.dropOldest() {
+			a.budget.drop()
+			return len(p), nil
+		}
+	}
+
+	line := make([]byte, len(p))
+	copy(line, p)
+
+	a.progress.Lock()
+	a.queued++
+	a.progress.Unlock()
+
+	a.lines <- line
+	return len(p), nil
+}
+
+// dropOldest drops the oldest queued line, if any, to make room for
+// a new one, reporting if there was one to drop
+func (a *asyncWriter) dropOldest() bool {
+	select {
+	case line := <-a.lines:
+		a.budget.release(len(line))
+		a.budget.drop()
+		a.markWritten()
+		return true
+	default:
+		return false
+	}
+}
+
+// markWritten records that a queued line was written, or dropped
+func (a *asyncWriter) markWritten() {
+	a.progress.Lock()
+	a.written++
+	a.flushed.Broadcast()
+	a.progress.Unlock()
+}
+
+// run writes the queued lines until the writer is closed, reporting
+// the number of lines dropped to stay within the budget, if any, each
+// time the queue empties
+func (a *asyncWriter) run() {
+	defer close(a.done)
+	for line := range a.lines {
+		if _, err := a.w.Write(line); err != nil {
+			fmt.Fprintf(os.Stderr, "logging: failed to write log line: %v\n", err)
+		}
+
+		a.budget.release(len(line))
+		a.markWritten()
+
+		if len(a.lines) == 0 {
+			a.reportDropped()
+		}
+	}
+	a.reportDropped()
+}
+
+// reportDropped reports to stderr the number of lines
+// dropped to stay within the budget since the last report
+func (a *asyncWriter) reportDropped() {
+	if n := a.budget.takeUnreported(); n > 0 {
+		fmt.Fprintf(
+			os.Stderr,
+			"logging: dropped %d buffered log lines over the %d MB buffer memory cap\n",
+			n, a.budget.limit>>20,
+		)
+	}
+}
+
+// Flush waits for the lines queued so far to be written,
+// while continuing to accept new ones
+func (a *asyncWriter) Flush() {
+	a.progress.Lock()
+	defer a.progress.Unlock()
+
+	target := a.queued
+	for a.written < target {
+		a.flushed.Wait()
+	}
+}
+
+// Close stops accepting lines and waits for those already
+// queued to be written. It may be called more than once.
+func (a *asyncWriter) Close() error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.lines)
+	}
+	a.mu.Unlock()
+
+	<-a.done
+	return nil
+}
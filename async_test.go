@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowWriter records the lines written to it, taking a while over each
+type slowWriter struct {
+	delay time.Duration
+
+	mu    sync.Mutex
+	lines []string
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(w.delay)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lines = append(w.lines, string(bytes.TrimSpace(p)))
+	return len(p), nil
+}
+
+func (w *slowWriter) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.lines)
+}
+
+func TestAsyncWriterFlush(t *testing.T) {
+	w := &slowWriter{delay: 5 * time.Millisecond}
+	a := newAsyncWriter(w, 16, nil)
+
+	for i := 0; i < 5; i++ {
+		a.Write([]byte("line\n"))
+	}
+	a.Flush()
+
+	if n := w.count(); n != 5 {
+		t.Errorf("got %d lines written after Flush, want 5", n)
+	}
+
+	a.Close()
+	if _, err := a.Write([]byte("late\n")); err != errAsyncClosed {
+		t.Errorf("Write after Close: got %v, want %v", err, errAsyncClosed)
+	}
+}
+
+func TestAsyncErrorFlushesBuffer(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		t.Run(name, func(t *testing.T) {
+			w := &slowWriter{delay: 20 * time.Millisecond}
+			l, err := NewClient(name, &Config{Writer: w, Async: true, BufferSize: 16})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Close()
+
+			for i := 0; i < 3; i++ {
+				l.Info("info")
+			}
+
+			if n := w.count(); n == 3 {
+				t.Error("info lines were written before returning, want them buffered")
+			}
+
+			l.Error("error")
+			if n := w.count(); n != 4 {
+				t.Errorf("got %d lines written after the error, want 4", n)
+			}
+		})
+	}
+}
+
+func TestAsyncCloseWritesAll(t *testing.T) {
+	const n = 200
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		t.Run(name, func(t *testing.T) {
+			w := &slowWriter{delay: 50 * time.Microsecond}
+			l, err := NewClient(name, &Config{Writer: w, Async: true, BufferSize: 16})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < n; i++ {
+				l.Info("info", Int("i", i))
+			}
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := w.count(); got != n {
+				t.Errorf("got %d lines written after Close, want %d", got, n)
+			}
+		})
+	}
+}
+
+func TestAsyncFlush(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		t.Run(name, func(t *testing.T) {
+			w := &slowWriter{delay: time.Millisecond}
+			l, err := NewClient(name, &Config{Writer: w, Async: true, BufferSize: 64})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Close()
+
+			for i := 0; i < 20; i++ {
+				l.Info("info")
+			}
+			if err := l.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if n := w.count(); n != 20 {
+				t.Errorf("got %d lines written after Flush, want 20", n)
+			}
+
+			// The logger is still usable after a Flush
+			l.Info("after")
+			if err := l.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if n := w.count(); n != 21 {
+				t.Errorf("got %d lines written after the second Flush, want 21", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package logging
+
+import (
+	"io/ioutil"
+	"strconv"
+	"testing"
+)
+
+// newBenchLogger returns a logger of the given backend writing to
+// ioutil.Discard, with the given Config settings
+func newBenchLogger(tb testing.TB, name string, cfg Config) Logger {
+	tb.Helper()
+
+	cfg.Writer = ioutil.Discard
+	l, err := NewClient(name, &cfg)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return l
+}
+
+// benchFields returns n fields of mixed types
+func benchFields(n int) []Field {
+	fields := make([]Field, 0, n)
+	for i := 0; i < n; i++ {
+		name := "field" + strconv.Itoa(i)
+		switch i % 3 {
+		case 0:
+			fields = append(fields, Str(name, "value"))
+		case 1:
+			fields = append(fields, Int(name, i))
+		default:
+			fields = append(fields, Bool(name, true))
+		}
+	}
+	return fields
+}
+
+var benchBackends = []string{"logrus", "zap", "slog"}
+
+func TestDisabledLevelAllocs(t *testing.T) {
+	fields := benchFields(5)
+	for _, name := range benchBackends {
+		l := newBenchLogger(t, name, Config{LogLevel: "info"})
+		child := l.WithFields(Str("child", "yes"))
+
+		if n := testing.AllocsPerRun(100, func() { l.Debug("disabled", fields...) }); n != 0 {
+			t.Errorf("%s: got %v allocations for a disabled Debug, want 0", name, n)
+		}
+		if n := testing.AllocsPerRun(100, func() { child.Debug("disabled", fields...) }); n != 0 {
+			t.Errorf("%s: got %v allocations for a disabled child Debug, want 0", name, n)
+		}
+	}
+}
+
+func BenchmarkInfoFields(b *testing.B) {
+	for _, name := range benchBackends {
+		for _, n := range []int{0, 1, 5, 10} {
+			fields := benchFields(n)
+			b.Run(name+"/"+strconv.Itoa(n), func(b *testing.B) {
+				l := newBenchLogger(b, name, Config{ReportCaller: new(bool)})
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					l.Info("message", fields...)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkDisabledDebug(b *testing.B) {
+	fields := benchFields(5)
+	for _, name := range benchBackends {
+		b.Run(name, func(b *testing.B) {
+			l := newBenchLogger(b, name, Config{LogLevel: "info"})
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				l.Debug("message", fields...)
+			}
+		})
+	}
+}
+
+func BenchmarkWithFields(b *testing.B) {
+	fields := benchFields(5)
+	for _, name := range benchBackends {
+		l := newBenchLogger(b, name, Config{ReportCaller: new(bool)})
+
+		b.Run(name+"/derive", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l.WithFields(fields...)
+			}
+		})
+
+		b.Run(name+"/log", func(b *testing.B) {
+			child := l.WithFields(fields...)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				child.Info("message")
+			}
+		})
+	}
+}
+
+func BenchmarkFormat(b *testing.B) {
+	fields := benchFields(5)
+	for _, name := range benchBackends {
+		for _, format := range []string{"json", "text"} {
+			b.Run(name+"/"+format, func(b *testing.B) {
+				l := newBenchLogger(b, name, Config{OutFormat: format, ReportCaller: new(bool)})
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					l.Info("message", fields...)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkReportCaller(b *testing.B) {
+	for _, name := range benchBackends {
+		for _, caller := range []bool{false, true} {
+			report := caller
+			b.Run(name+"/caller="+strconv.FormatBool(caller), func(b *testing.B) {
+				l := newBenchLogger(b, name, Config{ReportCaller: &report})
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					l.Info("message")
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkRedactPatterns(b *testing.B) {
+	fields := append(benchFields(4), Str("auth", "Bearer abc.def"))
+	patterns := []string{`\b\d{16}\b`, `Bearer \S+`}
+
+	for _, name := range benchBackends {
+		for _, n := range []int{0, len(patterns)} {
+			b.Run(name+"/"+strconv.Itoa(n), func(b *testing.B) {
+				l := newBenchLogger(b, name, Config{RedactPatterns: patterns[:n], ReportCaller: new(bool)})
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					l.Info("message", fields...)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkEmit(b *testing.B) {
+	fields := benchFields(5)
+	cases := []struct {
+		name string
+		cfg  Config
+		log  func(Logger)
+	}{
+		{"no-fields", Config{ReportCaller: new(bool)}, func(l Logger) { l.Info("message") }},
+		{"5-fields", Config{ReportCaller: new(bool)}, func(l Logger) { l.Info("message", fields...) }},
+		{"below-level", Config{LogLevel: "info"}, func(l Logger) { l.Debug("message", fields...) }},
+		{"source", Config{}, func(l Logger) { l.Info("message") }},
+	}
+
+	for _, name := range benchBackends {
+		for _, c := range cases {
+			b.Run(name+"/"+c.name, func(b *testing.B) {
+				l := newBenchLogger(b, name, c.cfg)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					c.log(l)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkMapify(b *testing.B) {
+	for _, n := range []int{1, 5, 10} {
+		fields := benchFields(n)
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				releaseMap(mapify(fields...))
+			}
+		})
+	}
+}
+
+func BenchmarkSuppressedDebugCaller(b *testing.B) {
+	for _, name := range benchBackends {
+		for _, caller := range []bool{false, true} {
+			report := caller
+			b.Run(name+"/caller="+strconv.FormatBool(caller), func(b *testing.B) {
+				l := newBenchLogger(b, name, Config{LogLevel: "info", ReportCaller: &report})
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					l.Debug("message")
+				}
+			})
+		}
+	}
+}
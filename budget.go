@@ -0,0 +1,81 @@
+package logging
+
+import "sync/atomic"
+
+// bufferBudget accounts the memory held by the buffering features of a
+// logger, its asynchronous output buffers and dedup window, against the
+// cap set by Config.MaxBufferMemoryMB. A nil bufferBudget is unlimited.
+type bufferBudget struct {
+	limit int64
+
+	// used, dropped and unreported are accessed atomically
+	used       int64
+	dropped    uint64
+	unreported uint64
+}
+
+// newBufferBudget returns a budget capping the memory held to the given
+// number of megabytes, or nil if it is not positive
+func newBufferBudget(maxMB int) *bufferBudget {
+	if maxMB <= 0 {
+		return nil
+	}
+	return &bufferBudget{limit: int64(maxMB) << 20}
+}
+
+// reserve accounts for n more bytes being held, unless
+// that would take the total held over the cap
+func (b *bufferBudget) reserve(n int) bool {
+	if b == nil {
+		return true
+	}
+
+	for {
+		used := atomic.LoadInt64(&b.used)
+		if used+int64(n) > b.limit {
+			return false
+		}
+
+		if atomic.CompareAndSwapInt64(&b.used, used, used+int64(n)) {
+			return true
+		}
+	}
+}
+
+// release accounts for n bytes reserved earlier no longer being held
+func (b *bufferBudget) release(n int) {
+	if b != nil {
+		atomic.AddInt64(&b.used, -int64(n))
+	}
+}
+
+// inUse returns the number of bytes currently held
+func (b *bufferBudget) inUse() int64 {
+	if b == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&b.used)
+}
+
+// drop counts a line dropped to stay within the cap
+func (b *bufferBudget) drop() {
+	atomic.AddUint64(&b.dropped, 1)
+	atomic.AddUint64(&b.unreported, 1)
+}
+
+// totalDropped returns the number of lines dropped so far
+func (b *bufferBudget) totalDropped() uint64 {
+	if b == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&b.dropped)
+}
+
+// takeUnreported returns the number of lines dropped since the last
+// call, for reporting them in a summary
+func (b *bufferBudget) takeUnreported() uint64 {
+	if b == nil {
+		return 0
+	}
+	return atomic.SwapUint64(&b.unreported, 0)
+}
@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAsyncWriterBudgetStorm(t *testing.T) {
+	const total = 100
+
+	budget := newBufferBudget(1)
+	w := &slowWriter{delay: time.Millisecond}
+	a := newAsyncWriter(w, 1024, budget)
+
+	line := append(bytes.Repeat([]byte("x"), 64<<10-1), '\n')
+	for i := 0; i < total; i++ {
+		if _, err := a.Write(line); err != nil {
+			t.Fatal(err)
+		}
+
+		if used := budget.inUse(); used > budget.limit {
+			t.Fatalf("write %d: %d bytes buffered, over the %d byte cap", i, used, budget.limit)
+		}
+	}
+	a.Close()
+
+	dropped := budget.totalDropped()
+	if dropped == 0 {
+		t.Error("no lines were dropped, want some over the cap")
+	}
+
+	if written := w.count(); written+int(dropped) != total {
+		t.Errorf("%d lines written and %d dropped, want %d in all", written, dropped, total)
+	}
+
+	if used := budget.inUse(); used != 0 {
+		t.Errorf("%d bytes still accounted for after Close, want 0", used)
+	}
+}
+
+func TestAsyncWriterBudgetFlush(t *testing.T) {
+	budget := newBufferBudget(1)
+	w := &slowWriter{delay: time.Millisecond}
+	a := newAsyncWriter(w, 1024, budget)
+	defer a.Close()
+
+	line := bytes.Repeat([]byte("x"), 256<<10)
+	for i := 0; i < 20; i++ {
+		a.Write(line)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		a.Flush()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Flush did not return with lines dropped")
+	}
+}
+
+func TestDedupBudget(t *testing.T) {
+	l, err := NewMemoryLogger(&Config{
+		DedupWindow:       time.Hour,
+		MaxBufferMemoryMB: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	l.Info("small")
+	l.Info("small")
+	if n := len(l.Entries()); n != 1 {
+		t.Errorf("got %d entries for a repeated small message, want 1", n)
+	}
+
+	big := strings.Repeat("x", 2<<20)
+	l.Info(big)
+	l.Info(big)
+
+	n := 0
+	for _, e := range l.Entries() {
+		if e.Msg == big {
+			n++
+		}
+	}
+	if n != 2 {
+		t.Errorf("got %d entries for a repeated message over the cap, want 2", n)
+	}
+}
+
+func TestValidateMaxBufferMemoryMB(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.MaxBufferMemoryMB = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an error for a negative MaxBufferMemoryMB")
+	}
+}
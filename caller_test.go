@@ -0,0 +1,40 @@
+package logging_test
+
+import (
+	"testing"
+
+	"github.com/brinick/logging"
+)
+
+// These tests are outside the package, as the caller reported
+// is the first frame outside it
+
+func TestCallerFile(t *testing.T) {
+	if err := logging.SetClient("memory", nil); err != nil {
+		t.Fatal(err)
+	}
+	l := logging.Client().(*logging.MemoryLogger)
+
+	paths := []struct {
+		name string
+		log  func()
+	}{
+		{"package level", func() { logging.Info("msg") }},
+		{"logger", func() { l.Info("msg") }},
+		{"child logger", func() { l.WithFields(logging.Str("k", "v")).Info("msg") }},
+		{"std logger", func() { logging.StdLogger().Print("msg") }},
+	}
+
+	for _, p := range paths {
+		l.Reset()
+		p.log()
+
+		entry, ok := l.LastEntry()
+		if !ok {
+			t.Fatalf("%s: nothing logged", p.name)
+		}
+		if got, _ := entry.Field("file"); got != "caller_test.go" {
+			t.Errorf("%s: got file %v, want caller_test.go", p.name, got)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+// Package cloudwatch provides a writer sending log lines to AWS CloudWatch
+// Logs, to be given as the Writer of a logging.Config. It is a module of
+// its own, so that the logging package itself does not depend on the AWS
+// SDK.
+package cloudwatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// The limits of a PutLogEvents call, where the size of an
+// event is that of its message plus an overhead of 26 bytes
+const (
+	maxBatchEvents = 10000
+	maxBatchSize   = 1048576
+	maxEventSize   = 262144
+	eventOverhead  = 26
+	maxBatchSpan   = 24 * time.Hour
+)
+
+// maxTokenRetries is the number of times a batch is sent again with
+// the sequence token expected by CloudWatch, after sending it with
+// an invalid one, before giving up
+const maxTokenRetries = 3
+
+// DefaultFlushInterval is the interval between sending the lines
+// written, if New is not given one
+const DefaultFlushInterval = 5 * time.Second
+
+// scheme prefixes the destination given to New
+const scheme = "cloudwatch://"
+
+// Client is the part of the CloudWatch Logs API used by the Writer,
+// implemented by *cloudwatchlogs.Client
+type Client interface {
+	PutLogEvents(
+		ctx context.Context,
+		params *cloudwatchlogs.PutLogEventsInput,
+		optFns ...func(*cloudwatchlogs.Options),
+	) (*cloudwatchlogs.PutLogEventsOutput, error)
+}
+
+// Writer batches the lines written to it as log events, which it puts
+// to its CloudWatch Logs stream once the batch is full, every flush
+// interval, and on Flush or Close. The batches respect the limits of
+// the PutLogEvents call, and carry the sequence token returned by the
+// previous one. Lines too long for an event are truncated.
+//
+// The json lines written are flattened, so that CloudWatch Logs Insights
+// discovers all their fields: nested objects are replaced by their fields,
+// named with the path to them joined by dots (e.g. "http.status").
+type Writer struct {
+	client Client
+	group  string
+	stream string
+
+	mu     sync.Mutex
+	events []types.InputLogEvent
+	size   int
+	closed bool
+
+	// putMu serializes the PutLogEvents calls,
+	// which carry the sequence token
+	putMu sync.Mutex
+	token *string
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// New returns a Writer to the destination cloudwatch://group/stream,
+// the stream being the last part of the path and the group the rest
+// (e.g. cloudwatch:///aws/lambda/fn/stream for the /aws/lambda/fn group).
+// The group and stream must already exist. The lines written are sent
+// every given interval, or every DefaultFlushInterval if it is not
+// positive.
+func New(dest string, client Client, interval time.Duration) (*Writer, error) {
+	group, stream, err := parseDest(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+
+	w := &Writer{
+		client: client,
+		group:  group,
+		stream: stream,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go w.run(interval)
+	return w, nil
+}
+
+// parseDest returns the group and stream of the given destination
+func parseDest(dest string) (string, string, error) {
+	if !strings.HasPrefix(dest, scheme) {
+		return "", "", fmt.Errorf("unknown CloudWatch destination %s. Legal: cloudwatch://group/stream", dest)
+	}
+
+	path := strings.TrimPrefix(dest, scheme)
+	i := strings.LastIndex(path, "/")
+	if i <= 0 || i == len(path)-1 {
+		return "", "", fmt.Errorf("unknown CloudWatch destination %s. Legal: cloudwatch://group/stream", dest)
+	}
+	return path[:i], path[i+1:], nil
+}
+
+// Write adds the line to the current batch, as a single event, first
+// putting the batch if the line would take it over the limits, in which
+// case any error raised doing so is returned
+func (w *Writer) Write(p []byte) (int, error) {
+	msg := eventMessage(p)
+	now := time.Now()
+	event := types.InputLogEvent{
+		Message:   aws.String(msg),
+		Timestamp: aws.Int64(now.UnixNano() / int64(time.Millisecond)),
+	}
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return 0, errors.New("cloudwatch: write to closed writer")
+	}
+
+	var full []types.InputLogEvent
+	if !w.fits(len(msg), now) {
+		full = w.take()
+	}
+	w.events = append(w.events, event)
+	w.size += len(msg) + eventOverhead
+	w.mu.Unlock()
+
+	if full != nil {
+		if err := w.put(full); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// fits reports if an event with a message of the given size, logged
+// at the given time, can be added to the current batch. It is called
+// with the lock held.
+func (w *Writer) fits(size int, at time.Time) bool {
+	if len(w.events) == 0 {
+		return true
+	}
+
+	first := time.Unix(0, *w.events[0].Timestamp*int64(time.Millisecond))
+	return len(w.events) < maxBatchEvents &&
+		w.size+size+eventOverhead <= maxBatchSize &&
+		at.Sub(first) < maxBatchSpan
+}
+
+// take returns the current batch, starting a new one.
+// It is called with the lock held.
+func (w *Writer) take() []types.InputLogEvent {
+	events := w.events
+	w.events, w.size = nil, 0
+	return events
+}
+
+// Flush puts the current batch, if any
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	events := w.take()
+	w.mu.Unlock()
+
+	if len(events) == 0 {
+		return nil
+	}
+	return w.put(events)
+}
+
+// Close stops the periodic flushing and puts the current batch, if any.
+// It may be called more than once, returning the result of the first call.
+func (w *Writer) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+
+		w.closeErr = w.Flush()
+
+		w.mu.Lock()
+		w.closed = true
+		w.mu.Unlock()
+	})
+	return w.closeErr
+}
+
+// run puts the current batch every interval, until the writer is
+// closed, reporting any error raised to stderr
+func (w *Writer) run(interval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if err := w.Flush(); err != nil {
+				fmt.Fprintf(os.Stderr, "logging: failed to put log events to CloudWatch: %v\n", err)
+			}
+		}
+	}
+}
+
+// put sends the events with the current sequence token, sending them
+// again with the expected one if CloudWatch reports it invalid
+func (w *Writer) put(events []types.InputLogEvent) error {
+	w.putMu.Lock()
+	defer w.putMu.Unlock()
+
+	for retries := 0; ; retries++ {
+		out, err := w.client.PutLogEvents(context.Background(), &cloudwatchlogs.PutLogEventsInput{
+			LogGroupName:  aws.String(w.group),
+			LogStreamName: aws.String(w.stream),
+			LogEvents:     events,
+			SequenceToken: w.token,
+		})
+		if err == nil {
+			w.token = out.NextSequenceToken
+			return nil
+		}
+
+		var accepted *types.DataAlreadyAcceptedException
+		if errors.As(err, &accepted) {
+			w.token = accepted.ExpectedSequenceToken
+			return nil
+		}
+
+		var invalid *types.InvalidSequenceTokenException
+		if !errors.As(err, &invalid) || retries == maxTokenRetries {
+			return err
+		}
+		w.token = invalid.ExpectedSequenceToken
+	}
+}
+
+// eventMessage returns the message of the event for the given line:
+// the line without its trailing newline, flattened if it is a json
+// object, and truncated to the maximum event size
+func eventMessage(p []byte) string {
+	line := bytes.TrimRight(p, "\r\n")
+	msg := string(line)
+	if flat, ok := flatten(line); ok {
+		msg = flat
+	}
+
+	if len(msg) > maxEventSize-eventOverhead {
+		msg = strings.ToValidUTF8(msg[:maxEventSize-eventOverhead], "")
+	}
+	return msg
+}
+
+// flatten returns the given json object with its nested objects replaced
+// by their fields, named with their path joined by dots. It returns false
+// if the line is not a json object or has no nested objects, to be
+// sent as is.
+func flatten(line []byte) (string, bool) {
+	if len(line) == 0 || line[0] != '{' {
+		return "", false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return "", false
+	}
+
+	nested := false
+	for _, v := range obj {
+		if _, ok := v.(map[string]interface{}); ok {
+			nested = true
+			break
+		}
+	}
+	if !nested {
+		return "", false
+	}
+
+	flat := make(map[string]interface{}, len(obj))
+	flattenInto(flat, "", obj)
+
+	data, err := json.Marshal(flat)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// flattenInto adds the fields of the given object to flat,
+// prefixing their names with the given prefix
+func flattenInto(flat map[string]interface{}, prefix string, obj map[string]interface{}) {
+	for k, v := range obj {
+		if nested, ok := v.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenInto(flat, prefix+k+".", nested)
+			continue
+		}
+		flat[prefix+k] = v
+	}
+}
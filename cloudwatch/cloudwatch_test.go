@@ -0,0 +1,291 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/brinick/logging"
+)
+
+// mockClient records the PutLogEvents calls made to it, checking their
+// sequence tokens as CloudWatch does
+type mockClient struct {
+	mu     sync.Mutex
+	token  int
+	puts   []*cloudwatchlogs.PutLogEventsInput
+	tokens []string
+
+	// fail, if set, is returned by the next call instead of its result
+	fail error
+}
+
+func (c *mockClient) PutLogEvents(
+	ctx context.Context,
+	params *cloudwatchlogs.PutLogEventsInput,
+	optFns ...func(*cloudwatchlogs.Options),
+) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tokens = append(c.tokens, aws.ToString(params.SequenceToken))
+	if err := c.fail; err != nil {
+		c.fail = nil
+		return nil, err
+	}
+
+	expected := c.expected()
+	if aws.ToString(params.SequenceToken) != expected {
+		return nil, &types.InvalidSequenceTokenException{
+			Message:               aws.String("invalid sequence token"),
+			ExpectedSequenceToken: aws.String(expected),
+		}
+	}
+
+	c.puts = append(c.puts, params)
+	c.token++
+	return &cloudwatchlogs.PutLogEventsOutput{NextSequenceToken: aws.String(c.expected())}, nil
+}
+
+// expected returns the sequence token expected by the next call,
+// none for the first one
+func (c *mockClient) expected() string {
+	if c.token == 0 {
+		return ""
+	}
+	return fmt.Sprintf("token-%d", c.token)
+}
+
+// batches returns the number of events of each successful call
+func (c *mockClient) batches() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var sizes []int
+	for _, p := range c.puts {
+		sizes = append(sizes, len(p.LogEvents))
+	}
+	return sizes
+}
+
+func newWriter(t *testing.T, client Client) *Writer {
+	t.Helper()
+
+	w, err := New("cloudwatch://group/stream", client, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestParseDest(t *testing.T) {
+	tests := []struct {
+		dest   string
+		group  string
+		stream string
+		ok     bool
+	}{
+		{"cloudwatch://group/stream", "group", "stream", true},
+		{"cloudwatch:///aws/lambda/fn/stream", "/aws/lambda/fn", "stream", true},
+		{"cloudwatch://group", "", "", false},
+		{"cloudwatch://group/", "", "", false},
+		{"cloudwatch:///stream", "", "", false},
+		{"file:///tmp/log", "", "", false},
+	}
+
+	for _, tt := range tests {
+		group, stream, err := parseDest(tt.dest)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v", tt.dest, err)
+			continue
+		}
+		if group != tt.group || stream != tt.stream {
+			t.Errorf("%s: got group %q and stream %q", tt.dest, group, stream)
+		}
+	}
+}
+
+func TestBatchEventLimit(t *testing.T) {
+	client := &mockClient{}
+	w := newWriter(t, client)
+
+	for i := 0; i < maxBatchEvents+1; i++ {
+		w.Write([]byte("line\n"))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := client.batches()
+	if len(got) != 2 || got[0] != maxBatchEvents || got[1] != 1 {
+		t.Errorf("got batches of %v events, want [%d 1]", got, maxBatchEvents)
+	}
+
+	if msg := aws.ToString(client.puts[0].LogEvents[0].Message); msg != "line" {
+		t.Errorf("got event message %q, want line", msg)
+	}
+	if g, s := aws.ToString(client.puts[0].LogGroupName), aws.ToString(client.puts[0].LogStreamName); g != "group" || s != "stream" {
+		t.Errorf("got group %q and stream %q", g, s)
+	}
+}
+
+func TestBatchSizeLimit(t *testing.T) {
+	client := &mockClient{}
+	w := newWriter(t, client)
+
+	line := strings.Repeat("x", 100<<10)
+	for i := 0; i < 25; i++ {
+		w.Write([]byte(line))
+	}
+	w.Close()
+
+	// 10 events of 100KB, with their overhead, fit in a batch, but not 11
+	got := client.batches()
+	if len(got) != 3 || got[0] != 10 || got[1] != 10 || got[2] != 5 {
+		t.Errorf("got batches of %v events, want [10 10 5]", got)
+	}
+
+	for i, p := range client.puts {
+		size := 0
+		for _, e := range p.LogEvents {
+			size += len(aws.ToString(e.Message)) + eventOverhead
+		}
+		if size > maxBatchSize {
+			t.Errorf("batch %d: %d bytes, over the %d byte limit", i, size, maxBatchSize)
+		}
+	}
+}
+
+func TestEventTruncated(t *testing.T) {
+	client := &mockClient{}
+	w := newWriter(t, client)
+
+	w.Write([]byte(strings.Repeat("x", maxEventSize+10)))
+	w.Close()
+
+	msg := aws.ToString(client.puts[0].LogEvents[0].Message)
+	if len(msg)+eventOverhead != maxEventSize {
+		t.Errorf("got an event of %d bytes, want %d", len(msg)+eventOverhead, maxEventSize)
+	}
+}
+
+func TestSequenceTokens(t *testing.T) {
+	client := &mockClient{}
+	w := newWriter(t, client)
+	defer w.Close()
+
+	for i := 0; i < 3; i++ {
+		w.Write([]byte("line\n"))
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"", "token-1", "token-2"}
+	if got := client.tokens; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got sequence tokens %q, want %q", got, want)
+	}
+}
+
+func TestInvalidSequenceToken(t *testing.T) {
+	// The stream was already written to, by another writer
+	client := &mockClient{token: 7}
+	w := newWriter(t, client)
+	defer w.Close()
+
+	w.Write([]byte("first\n"))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("second\n"))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"", "token-7", "token-8"}
+	if got := client.tokens; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got sequence tokens %q, want %q", got, want)
+	}
+	if got := client.batches(); len(got) != 2 {
+		t.Errorf("got %d batches put, want 2", len(got))
+	}
+}
+
+func TestDataAlreadyAccepted(t *testing.T) {
+	client := &mockClient{}
+	client.fail = &types.DataAlreadyAcceptedException{ExpectedSequenceToken: aws.String("token-5")}
+	client.token = 5
+
+	w := newWriter(t, client)
+	defer w.Close()
+
+	w.Write([]byte("first\n"))
+	if err := w.Flush(); err != nil {
+		t.Fatalf("got %v, want the batch taken as sent", err)
+	}
+
+	w.Write([]byte("second\n"))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"", "token-5"}
+	if got := client.tokens; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got sequence tokens %q, want %q", got, want)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	msg := eventMessage([]byte(`{"msg":"served","http":{"status":200,"req":{"method":"GET"}},"tags":["a"],"empty":{}}` + "\n"))
+
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(msg), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"msg":             "served",
+		"http.status":     float64(200),
+		"http.req.method": "GET",
+		"tags":            []interface{}{"a"},
+		"empty":           map[string]interface{}{},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, line := range []string{`{"msg":"flat","n":1}`, "level=info msg=text"} {
+		if got := eventMessage([]byte(line + "\n")); got != line {
+			t.Errorf("got %q, want the line unchanged", got)
+		}
+	}
+}
+
+func TestLoggerWriter(t *testing.T) {
+	client := &mockClient{}
+	w := newWriter(t, client)
+
+	l, err := logging.NewClient("zap", &logging.Config{Writer: w, OutFormat: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Info("hello", logging.F("req", map[string]interface{}{"id": 1}))
+	l.Close()
+	w.Close()
+
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(aws.ToString(client.puts[0].LogEvents[0].Message)), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["msg"] != "hello" || got["req.id"] != float64(1) {
+		t.Errorf("got event %v, want the msg and flattened req.id fields", got)
+	}
+}
@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// ContextEnricher is a function returning the fields to add to a log
+// entry, or a child logger, from the context passed to WithContext or
+// to one of the Ctx methods, such as the IDs of the current trace.
+// It appends them to the given fields, returned by the previous one.
+type ContextEnricher func(ctx context.Context, fields []Field) []Field
+
+// CtxLogLeveler defines the interface for log level methods taking
+// the context of the call, from which the registered ContextEnrichers
+// take fields to add to the entry. There is no fatal variant.
+type CtxLogLeveler interface {
+	TraceCtx(context.Context, string, ...Field)
+	DebugCtx(context.Context, string, ...Field)
+	InfoCtx(context.Context, string, ...Field)
+	WarnCtx(context.Context, string, ...Field)
+	ErrorCtx(context.Context, string, ...Field)
+}
+
+// sampledKey holds the context key registered by RegisterSampledKey
+var sampledKey atomic.Value
+
+// sampledKeyHolder wraps the registered key, so that keys of
+// different types can be stored in the same atomic.Value
+type sampledKeyHolder struct {
+	key interface{}
+}
+
+// RegisterSampledKey registers the context key under which the sampling
+// decision of the current trace is stored, as a bool, so that the log
+// verbosity follows it: for the Ctx methods, and the loggers returned by
+// WithContext, of every logger, entries are logged from the debug level
+// if the context is sampled and from the info level if it is not,
+// whatever the configured level. Contexts without the key are logged at
+// the configured level. A nil key unregisters it.
+func RegisterSampledKey(key interface{}) {
+	sampledKey.Store(sampledKeyHolder{key})
+}
+
+// sampledLevel returns the level set by the sampling
+// decision stored in the context, if any
+func sampledLevel(ctx context.Context) (string, bool) {
+	h, _ := sampledKey.Load().(sampledKeyHolder)
+	if h.key == nil || ctx == nil {
+		return "", false
+	}
+
+	sampled, ok := ctx.Value(h.key).(bool)
+	switch {
+	case !ok:
+		return "", false
+	case sampled:
+		return levelDebug, true
+	default:
+		return levelInfo, true
+	}
+}
+
+// AddContextEnricher registers a function that will be run on the
+// context passed to WithContext and the Ctx methods, in the order
+// of registration
+func (p *processor) AddContextEnricher(fn ContextEnricher) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ctxEnrichers = append(p.ctxEnrichers, fn)
+}
+
+// contextFields returns the fields taken from the
+// context by the registered ContextEnrichers
+func (p *processor) contextFields(ctx context.Context) []Field {
+	p.mu.RLock()
+	enrichers := p.ctxEnrichers
+	p.mu.RUnlock()
+
+	var fields []Field
+	for _, fn := range enrichers {
+		fields = fn(ctx, fields)
+	}
+	return fields
+}
+
+// WithContext returns a child logger adding the fields taken from
+// the context by the registered ContextEnrichers to every entry, and
+// logging at the level set by its sampling decision, if any, as
+// described by RegisterSampledKey
+func (e *emitter) WithContext(ctx context.Context) Logger {
+	fields := e.proc.contextFields(ctx)
+	bound := make([]Field, 0, len(e.bound)+len(fields))
+	bound = append(bound, e.bound...)
+	bound = append(bound, fields...)
+
+	ctxLevel := e.ctxLevel
+	if lvl, ok := sampledLevel(ctx); ok {
+		ctxLevel = lvl
+	}
+	return e.backend.derive(bound, e.component, ctxLevel)
+}
+
+// TraceCtx logs at the trace level, with the fields from the context
+func (e *emitter) TraceCtx(ctx context.Context, msg string, fields ...Field) {
+	e.emitCtx(ctx, levelTrace, msg, fields)
+}
+
+// DebugCtx logs at the debug level, with the fields from the context
+func (e *emitter) DebugCtx(ctx context.Context, msg string, fields ...Field) {
+	e.emitCtx(ctx, levelDebug, msg, fields)
+}
+
+// InfoCtx logs at the info level, with the fields from the context
+func (e *emitter) InfoCtx(ctx context.Context, msg string, fields ...Field) {
+	e.emitCtx(ctx, levelInfo, msg, fields)
+}
+
+// WarnCtx logs at the warn level, with the fields from the context
+func (e *emitter) WarnCtx(ctx context.Context, msg string, fields ...Field) {
+	e.emitCtx(ctx, levelWarn, msg, fields)
+}
+
+// ErrorCtx logs at the error level, with the fields from the context
+func (e *emitter) ErrorCtx(ctx context.Context, msg string, fields ...Field) {
+	e.emitCtx(ctx, levelError, msg, fields)
+}
+
+// emitCtx outputs a single log line at the given level, with the fields
+// from the context ahead of the given ones, if the level is enabled for
+// the sampling decision of the context, if any
+func (e *emitter) emitCtx(ctx context.Context, level, msg string, fields []Field) {
+	if lvl, ok := sampledLevel(ctx); ok {
+		scoped := *e
+		scoped.ctxLevel = lvl
+		e = &scoped
+	}
+
+	if !e.enabled(level) {
+		return
+	}
+	e.emit(level, msg, prependFields(e.proc.contextFields(ctx), fields))
+}
@@ -0,0 +1,161 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// requestKey is the context key of the request ID used by the tests
+type requestKey struct{}
+
+func requestEnricher(calls *int) ContextEnricher {
+	return func(ctx context.Context, fields []Field) []Field {
+		*calls++
+		if id, ok := ctx.Value(requestKey{}).(string); ok {
+			fields = append(fields, Str("request_id", id))
+		}
+		return fields
+	}
+}
+
+func TestContextEnricher(t *testing.T) {
+	l, err := NewMemoryLogger(&Config{LogLevel: "info"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls int
+	l.AddContextEnricher(requestEnricher(&calls))
+	ctx := context.WithValue(context.Background(), requestKey{}, "req-1")
+
+	l.InfoCtx(ctx, "ctx method", Str("k", "v"))
+	entry, _ := l.LastEntry()
+	if got, _ := entry.Field("request_id"); got != "req-1" {
+		t.Errorf("InfoCtx: got request_id %v, want req-1", got)
+	}
+	if got, _ := entry.Field("k"); got != "v" {
+		t.Errorf("InfoCtx: got k %v, want v", got)
+	}
+
+	l.WithContext(ctx).WithFields(Str("k", "v")).Warn("child logger")
+	entry, _ = l.LastEntry()
+	if got, _ := entry.Field("request_id"); got != "req-1" {
+		t.Errorf("WithContext: got request_id %v, want req-1", got)
+	}
+
+	calls = 0
+	l.DebugCtx(ctx, "disabled")
+	if calls != 0 {
+		t.Errorf("the context enricher was called %d times for a disabled level", calls)
+	}
+	if n := len(l.Entries()); n != 2 {
+		t.Errorf("got %d entries, want 2", n)
+	}
+}
+
+func TestMultiLoggerContextEnricher(t *testing.T) {
+	a, _ := NewMemoryLogger(nil)
+	b, _ := NewMemoryLogger(nil)
+	m := NewMultiLogger(a, b)
+
+	var calls int
+	m.AddContextEnricher(requestEnricher(&calls))
+	m.ErrorCtx(context.WithValue(context.Background(), requestKey{}, "req-2"), "failed")
+
+	for i, l := range []*MemoryLogger{a, b} {
+		entry, ok := l.LastEntry()
+		if !ok {
+			t.Fatalf("logger %d: nothing logged", i)
+		}
+		if got, _ := entry.Field("request_id"); got != "req-2" {
+			t.Errorf("logger %d: got request_id %v, want req-2", i, got)
+		}
+	}
+}
+
+// sampledTestKey is the context key of the sampling decision used by the tests
+type sampledTestKey struct{}
+
+func TestSampledContextLevel(t *testing.T) {
+	RegisterSampledKey(sampledTestKey{})
+	t.Cleanup(func() { RegisterSampledKey(nil) })
+
+	sampled := context.WithValue(context.Background(), sampledTestKey{}, true)
+	unsampled := context.WithValue(context.Background(), sampledTestKey{}, false)
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		level string
+		want  []string
+	}{
+		{"sampled", sampled, "warn", []string{"debug", "info", "warn"}},
+		{"unsampled", unsampled, "warn", []string{"info", "warn"}},
+		{"unsampled below info", unsampled, "trace", []string{"info", "warn"}},
+		{"no decision", context.Background(), "warn", []string{"warn"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := NewMemoryLogger(&Config{LogLevel: tt.level})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			check := func(how string) {
+				t.Helper()
+
+				var got []string
+				for _, e := range l.Entries() {
+					got = append(got, e.Level)
+				}
+				if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("%s: got levels %v, want %v", how, got, tt.want)
+				}
+				l.Reset()
+			}
+
+			l.TraceCtx(tt.ctx, "trace")
+			l.DebugCtx(tt.ctx, "debug")
+			l.InfoCtx(tt.ctx, "info")
+			l.WarnCtx(tt.ctx, "warn")
+			check("Ctx methods")
+
+			child := l.WithContext(tt.ctx).WithFields(Str("k", "v"))
+			child.Trace("trace")
+			child.Debug("debug")
+			child.Info("info")
+			child.Warn("warn")
+			check("WithContext")
+
+			l.Debug("plain")
+			if n := len(l.Entries()); (n == 1) != (levelRanks[tt.level] <= levelRanks[levelDebug]) {
+				t.Errorf("the sampling decision changed the level of the parent logger")
+			}
+		})
+	}
+}
+
+func TestSampledContextBackends(t *testing.T) {
+	RegisterSampledKey(sampledTestKey{})
+	t.Cleanup(func() { RegisterSampledKey(nil) })
+
+	sampled := context.WithValue(context.Background(), sampledTestKey{}, true)
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := NewClient(name, &Config{Writer: &buf, LogLevel: "warn"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			l.DebugCtx(sampled, "sampled debug")
+			l.Debug("plain debug")
+			if out := buf.String(); !strings.Contains(out, "sampled debug") || strings.Contains(out, "plain debug") {
+				t.Errorf("got output %q, want only the sampled debug entry", out)
+			}
+		})
+	}
+}
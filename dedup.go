@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// deduper collapses runs of identical entries: once an entry is logged,
+// those with the same level and message which follow it within the window
+// are suppressed, and counted in a summary line logged when a different
+// entry arrives or the window closes. The message held while the window
+// is open is accounted against the budget: if it does not fit, the entry
+// is logged without opening a window.
+type deduper struct {
+	window time.Duration
+	log    LogLeveler
+	budget *bufferBudget
+
+	mu         sync.Mutex
+	level      string
+	msg        string
+	suppressed int
+	timer      *time.Timer
+
+	// summaries are the summary lines being logged,
+	// which pass through without affecting the state
+	summaries map[string]int
+}
+
+func newDeduper(window time.Duration, l LogLeveler, budget *bufferBudget) *deduper {
+	return &deduper{
+		window: window,
+		log:    l,
+		budget: budget,
+	}
+}
+
+// allow reports if the entry with the given level and message should be
+// logged. Any pending summary of the previous entry is logged first.
+func (d *deduper) allow(level, msg string) bool {
+	d.mu.Lock()
+
+	if d.summaries[msg] > 0 {
+		d.mu.Unlock()
+		return true
+	}
+
+	if d.timer != nil && level == d.level && msg == d.msg {
+		d.suppressed++
+		d.mu.Unlock()
+		return false
+	}
+
+	summary := d.reset()
+	if d.budget.reserve(len(msg)) {
+		d.level, d.msg = level, msg
+		d.timer = time.AfterFunc(d.window, d.expire)
+	}
+	d.mu.Unlock()
+
+	summary()
+	return true
+}
+
+// expire closes the window of the current entry, logging its summary
+func (d *deduper) expire() {
+	d.mu.Lock()
+	summary := d.reset()
+	d.mu.Unlock()
+
+	summary()
+}
+
+// stop closes the window of the current entry without logging
+// its summary, for when the logger is reconfigured
+func (d *deduper) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reset()
+}
+
+// reset closes the window of the current entry, returning the function
+// logging its summary. It is called with the lock held, and the summary
+// logged once it is released, as that passes through allow in turn.
+func (d *deduper) reset() func() {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+		d.budget.release(len(d.msg))
+	}
+
+	level, msg, n := d.level, d.msg, d.suppressed
+	d.level, d.msg, d.suppressed = "", "", 0
+	if n == 0 {
+		return func() {}
+	}
+
+	summary := fmt.Sprintf("%s repeated %d times", msg, n)
+	if d.summaries == nil {
+		d.summaries = map[string]int{}
+	}
+	d.summaries[summary]++
+
+	return func() {
+		// the summary of repeated fatal entries is logged at info
+		log, _ := levelMethod(d.log, level)
+		log(summary)
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.summaries[summary]--; d.summaries[summary] == 0 {
+			delete(d.summaries, summary)
+		}
+	}
+}
+
+// describe returns the deduplication stage description for Pipeline
+func (d *deduper) describe() string {
+	return "dedup:" + d.window.String()
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// entryMsgs returns the messages of the entries recorded by l
+func entryMsgs(l *MemoryLogger) []string {
+	var msgs []string
+	for _, e := range l.Entries() {
+		msgs = append(msgs, e.Level+": "+e.Msg)
+	}
+	return msgs
+}
+
+func TestDedupFlushOnChange(t *testing.T) {
+	l, err := NewMemoryLogger(&Config{DedupWindow: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		l.Warn("retrying")
+	}
+	l.Info("retrying")
+	l.Error("connected")
+	l.Error("connected")
+
+	want := []string{
+		"warn: retrying",
+		"warn: retrying repeated 3 times",
+		"info: retrying",
+		"error: connected",
+	}
+	if got := entryMsgs(l); !reflect.DeepEqual(got, want) {
+		t.Errorf("got entries %q, want %q", got, want)
+	}
+}
+
+func TestDedupFlushOnTimeout(t *testing.T) {
+	l, err := NewMemoryLogger(&Config{DedupWindow: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		l.Warn("retrying")
+	}
+
+	e := waitForEntry(t, l, "retrying repeated 2 times")
+	if e.Level != "warn" {
+		t.Errorf("got the summary at %s, want warn", e.Level)
+	}
+
+	// The window is closed, so the message is logged again
+	l.Warn("retrying")
+	want := []string{"warn: retrying", "warn: retrying repeated 2 times", "warn: retrying"}
+	if got := entryMsgs(l); !reflect.DeepEqual(got, want) {
+		t.Errorf("got entries %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// truncatedValue replaces field value levels nested deeper than the limit
+const truncatedValue = "<truncated>"
+
+// limitDepth returns an Enricher that rewrites complex field values
+// (maps, slices, arrays, structs) into generic maps and slices, replacing
+// anything nested deeper than maxDepth levels with <truncated>. As each
+// level counts towards the limit this also protects against cyclic values.
+func limitDepth(maxDepth int) Enricher {
+	return func(fields []Field) []Field {
+		limited := make([]Field, len(fields))
+		for i, f := range fields {
+			f.Val = truncateDepth(reflect.ValueOf(f.Val), 1, maxDepth)
+			limited[i] = f
+		}
+		return limited
+	}
+}
+
+// truncateDepth walks the given value, which sits at the given depth,
+// converting containers to their generic form until maxDepth is exceeded.
+// Values that know how to format themselves are returned unchanged.
+func truncateDepth(v reflect.Value, depth, maxDepth int) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if formatsItself(v) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return truncateDepth(v.Elem(), depth, maxDepth)
+
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		if depth > maxDepth {
+			return truncatedValue
+		}
+
+	default:
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			m[key] = truncateDepth(iter.Value(), depth+1, maxDepth)
+		}
+		return m
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			// leave []byte to be formatted as is
+			return v.Interface()
+		}
+
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			s[i] = truncateDepth(v.Index(i), depth+1, maxDepth)
+		}
+		return s
+
+	default:
+		// struct
+		m := map[string]interface{}{}
+		for i := 0; i < v.NumField(); i++ {
+			sf := v.Type().Field(i)
+			if sf.PkgPath != "" {
+				// unexported
+				continue
+			}
+			m[sf.Name] = truncateDepth(v.Field(i), depth+1, maxDepth)
+		}
+		return m
+	}
+}
+
+// formatsItself reports if the value controls its own formatting,
+// in which case it should not be decomposed
+func formatsItself(v reflect.Value) bool {
+	if !v.CanInterface() {
+		return false
+	}
+
+	switch v.Interface().(type) {
+	case error, fmt.Stringer, json.Marshaler, encoding.TextMarshaler:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestLimitDepth(t *testing.T) {
+	nested := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": map[string]interface{}{
+				"c": map[string]interface{}{"d": 1},
+			},
+			"list": []int{1, 2},
+		},
+		"n": 1,
+	}
+
+	got := limitDepth(2)([]Field{F("nested", nested), Int("plain", 5)})
+
+	want := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b":    truncatedValue,
+			"list": truncatedValue,
+		},
+		"n": 1,
+	}
+	if !reflect.DeepEqual(got[0].Val, want) {
+		t.Errorf("got %v, want %v", got[0].Val, want)
+	}
+	if got[1].Val != 5 {
+		t.Errorf("got plain field %v, want it unchanged", got[1].Val)
+	}
+}
+
+func TestLimitDepthSelfReferential(t *testing.T) {
+	cyclic := map[string]interface{}{"name": "loop"}
+	cyclic["self"] = cyclic
+
+	var buf bytes.Buffer
+	l, err := NewLogrusLogger(&Config{Writer: &buf, OutFormat: "json", MaxFieldDepth: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("cyclic", F("value", cyclic))
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+
+	want := map[string]interface{}{
+		"name": "loop",
+		"self": map[string]interface{}{
+			"name": "loop",
+			"self": map[string]interface{}{
+				"name": "loop",
+				"self": truncatedValue,
+			},
+		},
+	}
+	if !reflect.DeepEqual(line["value"], want) {
+		t.Errorf("got %v, want %v", line["value"], want)
+	}
+}
@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Diff creates a Field describing what changed between the before and
+// after values. Structs are compared field by field (exported fields
+// only), and maps key by key. Other values are compared as a whole and
+// reported under the "value" key. In json output the field is an object
+// of changed keys, each holding the old and new values, while text output
+// gets a compact summary.
+func Diff(name string, before, after interface{}) Field {
+	return F(name, diffValues(before, after))
+}
+
+// change holds the before and after values of a changed key
+type change struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// changes maps changed keys to their before and after values
+type changes map[string]change
+
+// String returns a compact summary of the changes, sorted by key,
+// of the form key: old -> new, ...
+func (c changes) String() string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s: %v -> %v", k, c[k].Old, c[k].New)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// diffValues computes the changes between the two values
+func diffValues(before, after interface{}) changes {
+	var (
+		diff = changes{}
+		bv   = indirect(reflect.ValueOf(before))
+		av   = indirect(reflect.ValueOf(after))
+	)
+
+	switch {
+	case bv.IsValid() && av.IsValid() && bv.Type() == av.Type() && bv.Kind() == reflect.Struct:
+		for i := 0; i < bv.NumField(); i++ {
+			sf := bv.Type().Field(i)
+			if sf.PkgPath != "" {
+				// unexported
+				continue
+			}
+
+			was, now := bv.Field(i).Interface(), av.Field(i).Interface()
+			if !reflect.DeepEqual(was, now) {
+				diff[sf.Name] = change{was, now}
+			}
+		}
+
+	case bv.IsValid() && av.IsValid() && bv.Type() == av.Type() && bv.Kind() == reflect.Map:
+		for _, key := range bv.MapKeys() {
+			was, now := bv.MapIndex(key).Interface(), interface{}(nil)
+			if v := av.MapIndex(key); v.IsValid() {
+				now = v.Interface()
+			}
+
+			if !reflect.DeepEqual(was, now) {
+				diff[fmt.Sprint(key.Interface())] = change{was, now}
+			}
+		}
+
+		for _, key := range av.MapKeys() {
+			if !bv.MapIndex(key).IsValid() {
+				diff[fmt.Sprint(key.Interface())] = change{nil, av.MapIndex(key).Interface()}
+			}
+		}
+
+	default:
+		if !reflect.DeepEqual(before, after) {
+			diff["value"] = change{before, after}
+		}
+	}
+
+	return diff
+}
+
+// indirect follows pointers until reaching a non-pointer value
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
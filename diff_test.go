@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type diffConfig struct {
+	Name    string
+	Port    int
+	Tags    []string
+	Enabled bool
+	secret  string
+}
+
+func TestDiffStructs(t *testing.T) {
+	before := diffConfig{Name: "api", Port: 80, Tags: []string{"a"}, Enabled: true, secret: "x"}
+	after := diffConfig{Name: "api", Port: 8080, Tags: []string{"a", "b"}, Enabled: true, secret: "y"}
+
+	f := Diff("config", before, &after)
+	if f.Name != "config" {
+		t.Errorf("got field name %s, want config", f.Name)
+	}
+
+	want := changes{
+		"Port": {80, 8080},
+		"Tags": {[]string{"a"}, []string{"a", "b"}},
+	}
+	if got := f.Val.(changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("got changes %v, want %v", got, want)
+	}
+
+	if got := f.Val.(changes).String(); got != "Port: 80 -> 8080, Tags: [a] -> [a b]" {
+		t.Errorf("got summary %q", got)
+	}
+
+	if got := Diff("same", before, before).Val.(changes); len(got) != 0 {
+		t.Errorf("got changes %v for equal values, want none", got)
+	}
+}
+
+func TestDiffMapsAndValues(t *testing.T) {
+	got := Diff("m", map[string]int{"a": 1, "b": 2}, map[string]int{"a": 1, "b": 3, "c": 4}).Val.(changes)
+	want := changes{"b": {2, 3}, "c": {nil, 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("maps: got changes %v, want %v", got, want)
+	}
+
+	got = Diff("v", 1, 2).Val.(changes)
+	if want := (changes{"value": {1, 2}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("values: got changes %v, want %v", got, want)
+	}
+}
+
+func TestDiffOutput(t *testing.T) {
+	before := diffConfig{Name: "api", Port: 80}
+	after := diffConfig{Name: "web", Port: 80}
+
+	var buf bytes.Buffer
+	l, err := NewLogrusLogger(&Config{Writer: &buf, OutFormat: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("reconfigured", Diff("config", before, after))
+
+	var line struct {
+		Config map[string]change `json:"config"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	if got := line.Config["Name"]; got.Old != "api" || got.New != "web" {
+		t.Errorf("json: got Name change %+v, want api -> web", got)
+	}
+
+	buf.Reset()
+	l, err = NewLogrusLogger(&Config{Writer: &buf, OutFormat: "text"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("reconfigured", Diff("config", before, after))
+	if got := buf.String(); !strings.Contains(got, "Name: api -> web") {
+		t.Errorf("text: got %q, want the summary", got)
+	}
+}
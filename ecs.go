@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ecsVersion is the version of the Elastic Common Schema that is emitted
+const ecsVersion = "1.6.0"
+
+// ecsFormatter is a logrus formatter emitting entries as json shaped
+// according to the Elastic Common Schema (ECS)
+type ecsFormatter struct{}
+
+// Format renders a single log entry as an ECS json line. The timestamp,
+// level and message are mapped onto their ECS names, an error field is
+// nested under error.message (and error.stack_trace if the error provides
+// more detail when formatted with %+v) and all other fields are kept as is.
+func (f *ecsFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data := make(map[string]interface{}, len(entry.Data)+4)
+	for name, val := range entry.Data {
+		err, ok := val.(error)
+		switch {
+		case ok && name == errFieldName:
+			data["error"] = ecsError(err)
+		case ok:
+			// errors generally have no exported fields to marshal
+			data[name] = err.Error()
+		default:
+			data[name] = val
+		}
+	}
+
+	data["@timestamp"] = entry.Time.UTC().Format("2006-01-02T15:04:05.000Z07:00")
+	data["log.level"] = entry.Level.String()
+	data["message"] = entry.Message
+	data["ecs.version"] = ecsVersion
+
+	line, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to ECS json: %v", err)
+	}
+	return append(line, '\n'), nil
+}
+
+// ecsError converts an error to the ECS error object
+func ecsError(err error) map[string]interface{} {
+	obj := map[string]interface{}{
+		"message": err.Error(),
+	}
+
+	if detail := fmt.Sprintf("%+v", err); detail != err.Error() {
+		obj["stack_trace"] = detail
+	}
+	return obj
+}
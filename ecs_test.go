@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// stackError is an error giving more detail when formatted with %+v
+type stackError struct{}
+
+func (stackError) Error() string { return "disk full" }
+
+func (e stackError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprint(s, "disk full\nmain.write\n\tmain.go:10")
+		return
+	}
+	fmt.Fprint(s, e.Error())
+}
+
+// ecsLine logs a single entry with the given call to an ECS
+// formatted logger, returning the decoded line
+func ecsLine(t *testing.T, log func(Logger)) map[string]interface{} {
+	t.Helper()
+
+	var buf bytes.Buffer
+	l, err := NewLogrusLogger(&Config{Writer: &buf, OutFormat: "ecs", ReportCaller: new(bool)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log(l)
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	return line
+}
+
+func TestECSBasic(t *testing.T) {
+	line := ecsLine(t, func(l Logger) { l.Info("started", Str("service", "api"), Int("port", 80)) })
+
+	want := map[string]interface{}{
+		"log.level":   "info",
+		"message":     "started",
+		"ecs.version": ecsVersion,
+		"service":     "api",
+		"port":        float64(80),
+	}
+	for k, v := range want {
+		if line[k] != v {
+			t.Errorf("got %s %v, want %v", k, line[k], v)
+		}
+	}
+
+	stamp, ok := line["@timestamp"].(string)
+	if !ok {
+		t.Fatalf("got @timestamp %v, want a string", line["@timestamp"])
+	}
+	if _, err := time.Parse("2006-01-02T15:04:05.000Z07:00", stamp); err != nil || stamp[len(stamp)-1] != 'Z' {
+		t.Errorf("got @timestamp %q, want a UTC timestamp with milliseconds", stamp)
+	}
+}
+
+func TestECSError(t *testing.T) {
+	line := ecsLine(t, func(l Logger) {
+		l.Error("write failed", ErrField(stackError{}), F("cause", errors.New("quota")))
+	})
+
+	obj, ok := line["error"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("got error %v, want an object", line["error"])
+	}
+	if obj["message"] != "disk full" {
+		t.Errorf("got error.message %v, want disk full", obj["message"])
+	}
+	if obj["stack_trace"] != "disk full\nmain.write\n\tmain.go:10" {
+		t.Errorf("got error.stack_trace %q", obj["stack_trace"])
+	}
+	if line["cause"] != "quota" {
+		t.Errorf("got cause %v, want the error message", line["cause"])
+	}
+	if line["log.level"] != "error" {
+		t.Errorf("got log.level %v, want error", line["log.level"])
+	}
+
+	line = ecsLine(t, func(l Logger) { l.Error("failed", ErrField(errors.New("plain"))) })
+	if obj := line["error"].(map[string]interface{}); obj["message"] != "plain" || obj["stack_trace"] != nil {
+		t.Errorf("got error %v, want only the message of a plain error", obj)
+	}
+}
@@ -0,0 +1,310 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// backend is implemented by each of the loggers wrapping a logging
+// package, providing what the emit pipeline shared by all of them needs
+type backend interface {
+	// levelEnabled reports if the configured level of the
+	// logger lets through entries at the given canonical level
+	levelEnabled(level string) bool
+
+	// write outputs a single entry, once it has
+	// passed through the processor
+	write(level, msg string, fields []Field) error
+
+	// exit closes the logger and exits the program, if so configured,
+	// after an entry has been logged at the fatal level
+	exit()
+
+	// derive returns a logger sharing the configuration of this
+	// one, binding the given fields, component and context level
+	derive(bound []Field, component, ctxLevel string) Logger
+}
+
+// emitter implements the level methods, and the other methods of a Logger
+// that do not depend on the backend, by passing each entry through the
+// processor before handing it to the backend to write. It is embedded by
+// each of the backend loggers.
+type emitter struct {
+	proc    *processor
+	backend backend
+
+	// bound are the fields attached to every entry by this logger,
+	// as created by WithFields
+	bound []Field
+
+	// component is the name given to this logger by Named, whose
+	// level in Config.ComponentLevels, if any, overrides the LogLevel
+	component string
+
+	// ctxLevel is the level set by the sampling decision of the context
+	// given to WithContext, if any, which overrides both of the above
+	ctxLevel string
+}
+
+// Trace defines the trace level for this logger
+func (e *emitter) Trace(msg string, fields ...Field) {
+	e.emit(levelTrace, msg, fields)
+}
+
+// TraceL defines the trace level for more than one log line
+func (e *emitter) TraceL(msgs []string, fields ...Field) {
+	e.emitL(levelTrace, msgs, fields)
+}
+
+// Debug defines the debug level for this logger
+func (e *emitter) Debug(msg string, fields ...Field) {
+	e.emit(levelDebug, msg, fields)
+}
+
+// DebugL defines the debug level for more than one log line
+func (e *emitter) DebugL(msgs []string, fields ...Field) {
+	e.emitL(levelDebug, msgs, fields)
+}
+
+// Info defines the info level for this logger
+func (e *emitter) Info(msg string, fields ...Field) {
+	e.emit(levelInfo, msg, fields)
+}
+
+// InfoL defines the info level for more than one log line
+func (e *emitter) InfoL(msgs []string, fields ...Field) {
+	e.emitL(levelInfo, msgs, fields)
+}
+
+// Warn defines the warn level for this logger
+func (e *emitter) Warn(msg string, fields ...Field) {
+	e.emit(levelWarn, msg, fields)
+}
+
+// WarnL defines the warn level for more than one log line
+func (e *emitter) WarnL(msgs []string, fields ...Field) {
+	e.emitL(levelWarn, msgs, fields)
+}
+
+// Error defines the error level for this logger
+func (e *emitter) Error(msg string, fields ...Field) {
+	e.emit(levelError, msg, fields)
+}
+
+// ErrorL defines the error level for more than one log line
+func (e *emitter) ErrorL(msgs []string, fields ...Field) {
+	e.emitL(levelError, msgs, fields)
+}
+
+// Fatal defines the fatal level for this logger. It then closes
+// the logger and exits, unless ExitOnFatal is false.
+func (e *emitter) Fatal(msg string, fields ...Field) {
+	e.emit(levelFatal, msg, fields)
+	e.backend.exit()
+}
+
+// FatalL defines the fatal level for more than one log line
+func (e *emitter) FatalL(msgs []string, fields ...Field) {
+	e.emitL(levelFatal, msgs, fields)
+	e.backend.exit()
+}
+
+// logFatal and logFatalL log at the fatal level without exiting,
+// so that a MultiLogger can write to all its loggers before doing so
+func (e *emitter) logFatal(msg string, fields []Field) {
+	e.emit(levelFatal, msg, fields)
+}
+
+func (e *emitter) logFatalL(msgs []string, fields []Field) {
+	e.emitL(levelFatal, msgs, fields)
+}
+
+// TryTrace logs at the trace level, returning any error raised while
+// writing the entry or firing its hooks
+func (e *emitter) TryTrace(msg string, fields ...Field) error {
+	return e.tryEmit(levelTrace, msg, fields)
+}
+
+// TryDebug logs at the debug level, returning any error raised while
+// writing the entry or firing its hooks
+func (e *emitter) TryDebug(msg string, fields ...Field) error {
+	return e.tryEmit(levelDebug, msg, fields)
+}
+
+// TryInfo logs at the info level, returning any error raised while
+// writing the entry or firing its hooks
+func (e *emitter) TryInfo(msg string, fields ...Field) error {
+	return e.tryEmit(levelInfo, msg, fields)
+}
+
+// TryWarn logs at the warn level, returning any error raised while
+// writing the entry or firing its hooks
+func (e *emitter) TryWarn(msg string, fields ...Field) error {
+	return e.tryEmit(levelWarn, msg, fields)
+}
+
+// TryError logs at the error level, returning any error raised while
+// writing the entry or firing its hooks
+func (e *emitter) TryError(msg string, fields ...Field) error {
+	return e.tryEmit(levelError, msg, fields)
+}
+
+// IsEnabled reports if an entry at the given level would currently be
+// output, so that costly fields need only be built when it would.
+// It returns false for an unknown level.
+func (e *emitter) IsEnabled(level string) bool {
+	lvl, err := parseLevel(level)
+	return err == nil && e.enabled(lvl)
+}
+
+// Writer returns a writer logging each line written to it as a message
+// at the given level, for libraries which log to an io.Writer. A partial
+// line is kept until the rest of it is written.
+func (e *emitter) Writer(level string) io.Writer {
+	return newLevelWriter(e, level)
+}
+
+// Printf logs the formatted message at the configured PrintLevel
+func (e *emitter) Printf(format string, args ...interface{}) {
+	e.printMethod()(fmt.Sprintf(format, args...))
+}
+
+// Println logs the arguments, formatted as for fmt.Println,
+// at the configured PrintLevel
+func (e *emitter) Println(args ...interface{}) {
+	e.printMethod()(sprintln(args...))
+}
+
+// printMethod returns the method logging at the configured PrintLevel,
+// info if it is not set, Configure having rejected any unknown one
+func (e *emitter) printMethod() func(string, ...Field) {
+	log, _ := levelMethod(e, e.proc.options().printLevel)
+	return log
+}
+
+// LogEvery logs the message at the given level, unless the same message
+// was already logged via LogEvery within the given duration
+func (e *emitter) LogEvery(d time.Duration, level, msg string, fields ...Field) {
+	logEvery(e, &e.proc.limiter, d, level, msg, fields)
+}
+
+// LogStatus logs the message at the level for the given HTTP status code,
+// with the code as the status field: error for 5xx, warn for 4xx and
+// info for anything else
+func (e *emitter) LogStatus(status int, msg string, fields ...Field) {
+	logStatus(e, status, msg, fields)
+}
+
+// WithFields returns a child logger that adds the given fields to every
+// entry it logs, on top of any already bound to this logger. Per-call
+// fields of the same name take priority over bound ones. The child shares
+// this logger's configuration, so reconfiguring either affects both.
+func (e *emitter) WithFields(fields ...Field) Logger {
+	bound := make([]Field, 0, len(e.bound)+len(fields))
+	bound = append(bound, e.bound...)
+	bound = append(bound, fields...)
+	return e.backend.derive(bound, e.component, e.ctxLevel)
+}
+
+// Named returns a child logger for the given component, adding it as
+// the component field of every entry, and logging at the level set
+// for it in Config.ComponentLevels, if any
+func (e *emitter) Named(name string) Logger {
+	bound := make([]Field, 0, len(e.bound)+1)
+	bound = append(bound, e.bound...)
+	bound = append(bound, Str("component", name))
+	return e.backend.derive(bound, name, e.ctxLevel)
+}
+
+// WithCorrelationID returns a child logger adding the given ID to every
+// entry it logs, as the field named by Config.CorrelationKey
+func (e *emitter) WithCorrelationID(id string) Logger {
+	return e.WithFields(Str(e.proc.options().correlationKey, id))
+}
+
+// enabled reports if an entry at the given level would be output.
+// It is checked before any fields are built so that disabled levels
+// cost as little as possible.
+func (e *emitter) enabled(level string) bool {
+	if e.ctxLevel != "" {
+		if levelRanks[level] < levelRanks[e.ctxLevel] {
+			return false
+		}
+	} else if lvl, ok := e.proc.componentLevel(e.component); ok {
+		if levelRanks[level] < levelRanks[lvl] {
+			return false
+		}
+	} else if !e.backend.levelEnabled(level) {
+		return false
+	}
+	return levelRanks[level] >= levelRanks[levelError] || !e.proc.quieted()
+}
+
+// emit outputs a single log line at the given level,
+// reporting any error raised to stderr
+func (e *emitter) emit(level, msg string, fields []Field) {
+	if err := e.tryEmit(level, msg, fields); err != nil {
+		fmt.Fprintf(os.Stderr, "logging: failed to write log line: %v\n", err)
+	}
+}
+
+// tryEmit outputs a single log line at the given level, returning
+// any error raised on writing or by the hooks
+func (e *emitter) tryEmit(level, msg string, fields []Field) error {
+	if !e.enabled(level) || !e.proc.admit(level, msg) {
+		return nil
+	}
+
+	opts := e.proc.options()
+	if opts.panicSafe {
+		defer e.proc.recoverPanic()
+	}
+
+	fields = e.proc.defaults(level, prependFields(e.bound, fields))
+	fields = e.proc.enrich(append(fields, opts.source()...))
+
+	msg, fields, ok := e.proc.filter(level, msg, fields)
+	if !ok {
+		return nil
+	}
+	return e.deliver(level, msg, fields)
+}
+
+// emitL outputs each of the given lines at the given level
+func (e *emitter) emitL(level string, msgs []string, fields []Field) {
+	if !e.enabled(level) {
+		return
+	}
+
+	if e.proc.options().panicSafe {
+		defer e.proc.recoverPanic()
+	}
+
+	fields = e.proc.enrich(e.proc.defaults(level, prependFields(e.bound, fields)))
+	for _, line := range msgs {
+		if !e.proc.admit(level, line) {
+			continue
+		}
+
+		line, lineFields, ok := e.proc.filter(level, line, fields)
+		if !ok {
+			continue
+		}
+
+		if err := e.deliver(level, line, lineFields); err != nil {
+			fmt.Fprintf(os.Stderr, "logging: failed to write log line: %v\n", err)
+		}
+	}
+}
+
+// deliver hands the processed entry to the backend, then fires the hooks,
+// returning the first error raised by either
+func (e *emitter) deliver(level, msg string, fields []Field) error {
+	err := e.backend.write(level, msg, fields)
+	if hookErr := e.proc.fire(level, msg, fields); err == nil {
+		err = hookErr
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"os"
+	"strings"
+)
+
+// Environment variables read by NewClientFromEnv and SetClientFromEnv
+const (
+	envLevel   = "LOG_LEVEL"
+	envFormat  = "LOG_FORMAT"
+	envFile    = "LOG_FILE"
+	envBackend = "LOG_BACKEND"
+)
+
+// defaultEnvBackend is the client used if LOG_BACKEND is unset
+const defaultEnvBackend = "logrus"
+
+// configFromEnv returns the client name and Config given by
+// the environment, with unset variables left empty
+func configFromEnv() (string, *Config) {
+	name := strings.TrimSpace(os.Getenv(envBackend))
+	if name == "" {
+		name = defaultEnvBackend
+	}
+
+	return name, &Config{
+		LogLevel:  os.Getenv(envLevel),
+		OutFormat: os.Getenv(envFormat),
+		Outfile:   os.Getenv(envFile),
+	}
+}
+
+// NewClientFromEnv returns a new logging client configured from the
+// environment: LOG_BACKEND names the client, as for NewClient (default
+// logrus), and LOG_LEVEL, LOG_FORMAT and LOG_FILE set the LogLevel,
+// OutFormat and Outfile Config fields. Unset variables keep the client
+// defaults, and invalid values are an error.
+func NewClientFromEnv() (Logger, error) {
+	name, cfg := configFromEnv()
+	return NewClient(name, cfg)
+}
+
+// SetClientFromEnv sets the package-level logger, as for SetClient,
+// configured from the environment as for NewClientFromEnv
+func SetClientFromEnv() error {
+	name, cfg := configFromEnv()
+	return SetClient(name, cfg)
+}
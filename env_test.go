@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetClientFromEnv(t *testing.T) {
+	resetClient(t)
+	t.Setenv(envBackend, "memory")
+	t.Setenv(envLevel, "warn")
+
+	if err := SetClientFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+
+	l := Client()
+	if l.Name() != "memory" || l.GetLevel() != "warn" {
+		t.Errorf("got %s logger at %s, want memory at warn", l.Name(), l.GetLevel())
+	}
+}
+
+func TestSetClientFromEnvUnknownBackend(t *testing.T) {
+	resetClient(t)
+	t.Setenv(envBackend, "bogus")
+
+	err := SetClientFromEnv()
+	if err == nil || !strings.Contains(err.Error(), "unknown logging client type bogus") {
+		t.Errorf("got error %v, want an unknown client error", err)
+	}
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	for _, name := range []string{envBackend, envLevel, envFormat, envFile} {
+		t.Setenv(name, "")
+	}
+
+	l, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Name() != "logrus" || l.GetLevel() != "info" || l.Path() != "" {
+		t.Errorf("got %s logger at %s to %q, want the logrus defaults", l.Name(), l.GetLevel(), l.Path())
+	}
+
+	outfile := filepath.Join(t.TempDir(), "app.log")
+	t.Setenv(envBackend, "zap")
+	t.Setenv(envLevel, "debug")
+	t.Setenv(envFormat, "json")
+	t.Setenv(envFile, outfile)
+
+	l, err = NewClientFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Debug("from env")
+	l.Close()
+
+	data, err := ioutil.ReadFile(outfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); l.Name() != "zap" || !strings.Contains(got, `"msg":"from env"`) {
+		t.Errorf("got %s logger writing %q, want zap writing the debug json entry", l.Name(), got)
+	}
+}
+
+func TestNewClientFromEnvInvalid(t *testing.T) {
+	for name, val := range map[string]string{envLevel: "loud", envFormat: "xml"} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, val)
+			if _, err := NewClientFromEnv(); err == nil || !strings.Contains(err.Error(), val) {
+				t.Errorf("got error %v, want one for %s", err, val)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// epochValue returns the time as a Unix timestamp,
+// in seconds or milliseconds
+func epochValue(t time.Time, millis bool) int64 {
+	if millis {
+		return t.UnixNano() / int64(time.Millisecond)
+	}
+	return t.Unix()
+}
+
+// timestamp returns the current time, in UTC if utc is set
+func timestamp(utc bool) time.Time {
+	if utc {
+		return time.Now().UTC()
+	}
+	return time.Now()
+}
+
+// epochJSONFormatter is a logrus formatter emitting json with the time
+// as a numeric Unix timestamp. The wrapped JSONFormatter is set up not to
+// add the time itself, so the timestamp is added as a field, moving any
+// field of the same name aside as logrus does for the other clashes.
+type epochJSONFormatter struct {
+	json    *logrus.JSONFormatter
+	timeKey string
+	millis  bool
+}
+
+func newEpochJSONFormatter(f *logrus.JSONFormatter, millis bool) *epochJSONFormatter {
+	timeKey := "time"
+	if key, ok := f.FieldMap[logrus.FieldKeyTime]; ok {
+		timeKey = key
+	}
+
+	fieldMap := logrus.FieldMap{}
+	for k, v := range f.FieldMap {
+		fieldMap[k] = v
+	}
+
+	// The time key is added here, so must not be moved aside by logrus
+	fieldMap[logrus.FieldKeyTime] = "\x00" + timeKey
+
+	json := *f
+	json.FieldMap = fieldMap
+	json.DisableTimestamp = true
+
+	return &epochJSONFormatter{
+		json:    &json,
+		timeKey: timeKey,
+		millis:  millis,
+	}
+}
+
+// Format renders a single log entry as a json line
+func (f *epochJSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data := make(logrus.Fields, len(entry.Data)+1)
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+
+	if v, ok := data[f.timeKey]; ok {
+		data["fields."+f.timeKey] = v
+	}
+	data[f.timeKey] = epochValue(entry.Time, f.millis)
+
+	timed := *entry
+	timed.Data = data
+	return f.json.Format(&timed)
+}
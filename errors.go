@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+)
+
+// expandErrors is an Enricher adding, for each field holding an error
+// which wraps others, a <name>.causes field listing the messages of the
+// wrapped errors, outermost first, and for each error whose %+v form
+// gives more detail (e.g. a stack trace), a <name>.detail field with it
+func expandErrors(fields []Field) []Field {
+	expanded := make([]Field, 0, len(fields))
+	for _, f := range fields {
+		expanded = append(expanded, f)
+
+		err, ok := f.Val.(error)
+		if !ok || err == nil {
+			continue
+		}
+
+		var causes []string
+		for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(cause) {
+			causes = append(causes, cause.Error())
+		}
+
+		if len(causes) > 0 {
+			expanded = append(expanded, F(f.Name+".causes", causes))
+		}
+
+		if detail := fmt.Sprintf("%+v", err); detail != err.Error() {
+			expanded = append(expanded, F(f.Name+".detail", detail))
+		}
+	}
+	return expanded
+}
@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// useMemoryClient sets a MemoryLogger as the package-level logger
+// for the duration of the test, returning it
+func useMemoryClient(t *testing.T) *MemoryLogger {
+	t.Helper()
+	resetClient(t)
+
+	l, err := SetClientGet("memory", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l.(*MemoryLogger)
+}
+
+func TestLogError(t *testing.T) {
+	l := useMemoryClient(t)
+
+	if err := LogError(nil, "not logged"); err != nil {
+		t.Errorf("got %v for a nil error, want nil", err)
+	}
+	if n := len(l.Entries()); n != 0 {
+		t.Fatalf("got %d entries for a nil error, want none", n)
+	}
+
+	want := errors.New("boom")
+	if err := LogError(want, "failed", Str("op", "read")); err != want {
+		t.Errorf("got %v, want the same error back", err)
+	}
+
+	entry, ok := l.LastEntry()
+	if !ok {
+		t.Fatal("nothing logged for a non-nil error")
+	}
+	if entry.Level != "error" || entry.Msg != "failed" {
+		t.Errorf("got %s %q, want error \"failed\"", entry.Level, entry.Msg)
+	}
+	if got, _ := entry.Field("err"); got != want {
+		t.Errorf("got err field %v, want %v", got, want)
+	}
+	if got, _ := entry.Field("op"); got != "read" {
+		t.Errorf("got op field %v, want read", got)
+	}
+}
+
+func TestErrFieldNil(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		for _, format := range []string{"json", "text"} {
+			var buf bytes.Buffer
+			l, err := NewClient(name, &Config{Writer: &buf, OutFormat: format, ReportCaller: new(bool)})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			l.Info("nothing failed", ErrField(nil))
+			l.WithFields(ErrField(nil)).Info("child")
+			if got := buf.String(); strings.Contains(got, "err") {
+				t.Errorf("%s %s: got %q, want no err key", name, format, got)
+			}
+		}
+	}
+
+	if f := ErrField(nil); f != (Field{}) {
+		t.Errorf("got %v for a nil error, want the empty Field", f)
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	root := errors.New("connection refused")
+	wrapped := fmt.Errorf("query users: %w", fmt.Errorf("dial db: %w", root))
+
+	l, err := NewMemoryLogger(&Config{ExpandErrors: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Error("failed", ErrField(wrapped), F("other", stackError{}), ErrField(nil))
+	entry, _ := l.LastEntry()
+
+	want := []string{"dial db: connection refused", "connection refused"}
+	if got, _ := entry.Field("err.causes"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got err.causes %v, want %v", got, want)
+	}
+	if got, ok := entry.Field("err.detail"); ok {
+		t.Errorf("got err.detail %v, want none for a plain error", got)
+	}
+	if got, _ := entry.Field("other.detail"); got != fmt.Sprintf("%+v", stackError{}) {
+		t.Errorf("got other.detail %q, want the %%+v form", got)
+	}
+	if got, ok := entry.Field("other.causes"); ok {
+		t.Errorf("got other.causes %v, want none for an unwrapped error", got)
+	}
+
+	// Not expanded unless requested
+	plain, err := NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain.Error("failed", ErrField(wrapped))
+	entry, _ = plain.LastEntry()
+	if got, ok := entry.Field("err.causes"); ok {
+		t.Errorf("got err.causes %v without ExpandErrors", got)
+	}
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"sync"
+	"time"
+)
+
+// everySweepInterval is how often expired messages are
+// dropped by a rateLimiter, so that it does not grow unbounded
+const everySweepInterval = time.Minute
+
+// rateLimiter tracks when each message logged via LogEvery may next be
+// logged. Its zero value is ready to use.
+type rateLimiter struct {
+	mu        sync.Mutex
+	next      map[string]time.Time
+	lastSweep time.Time
+}
+
+// allow reports if the given message may be logged now, and if so
+// holds back any repeat of it for the given duration
+func (r *rateLimiter) allow(msg string, d time.Duration) bool {
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.next == nil {
+		r.next = map[string]time.Time{}
+		r.lastSweep = now
+	}
+
+	if now.Sub(r.lastSweep) >= everySweepInterval {
+		for m, next := range r.next {
+			if !now.Before(next) {
+				delete(r.next, m)
+			}
+		}
+		r.lastSweep = now
+	}
+
+	if now.Before(r.next[msg]) {
+		return false
+	}
+
+	r.next[msg] = now.Add(d)
+	return true
+}
+
+// logEvery logs the message via the method of l for the given level,
+// unless the same message was already logged by it within d. A message
+// for an unknown level, including fatal, is not logged, the level being
+// reported once to stderr.
+func logEvery(l LogLeveler, r *rateLimiter, d time.Duration, level, msg string, fields []Field) {
+	log, err := levelMethod(l, level)
+	if err != nil {
+		reportUnknownLevel("LogEvery", level, err)
+		return
+	}
+
+	if r.allow(msg, d) {
+		log(msg, fields...)
+	}
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogEvery(t *testing.T) {
+	l, err := NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const window = 50 * time.Millisecond
+	for i := 0; i < 100; i++ {
+		l.LogEvery(window, "warn", "retrying", Int("i", i))
+	}
+	l.LogEvery(window, "warn", "other")
+
+	entries := l.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want the first retrying and the other", len(entries))
+	}
+	if got, _ := entries[0].Field("i"); got != 0 || entries[0].Level != "warn" {
+		t.Errorf("got %s entry with i=%v, want the first warn", entries[0].Level, got)
+	}
+
+	time.Sleep(window + 10*time.Millisecond)
+	l.LogEvery(window, "warn", "retrying")
+	if n := len(l.Entries()); n != 3 {
+		t.Errorf("got %d entries, want the message logged again after the window", n)
+	}
+}
+
+func TestLogEveryUnknownLevel(t *testing.T) {
+	streams := captureStreams(t)
+	l, err := NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.LogEvery(time.Minute, "eror", "not logged")
+	l.LogEvery(time.Minute, "eror", "not logged either")
+	l.LogEvery(time.Minute, "error", "not logged")
+
+	entries := l.Entries()
+	if len(entries) != 1 || entries[0].Level != "error" {
+		t.Fatalf("got %v, want only the error entry, not held back by the rejected one", entries)
+	}
+	if _, stderr := streams(); strings.Count(stderr, "unknown log level: eror") != 1 {
+		t.Errorf("got stderr %q, want the eror level reported once", stderr)
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	var r rateLimiter
+	r.allow("old", time.Millisecond)
+	r.allow("current", time.Hour)
+
+	time.Sleep(2 * time.Millisecond)
+	r.mu.Lock()
+	r.lastSweep = r.lastSweep.Add(-everySweepInterval)
+	r.mu.Unlock()
+
+	r.allow("new", time.Hour)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.next["old"]; ok {
+		t.Error("got the expired message kept after the sweep")
+	}
+	if _, ok := r.next["current"]; !ok {
+		t.Error("got the held back message dropped by the sweep")
+	}
+}
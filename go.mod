@@ -1,8 +1,17 @@
 module github.com/brinick/logging
 
-go 1.14
+go 1.21
 
 require (
 	github.com/brinick/fs v0.0.0-20200323111627-1a7de91c34e8
 	github.com/sirupsen/logrus v1.4.2
+	go.uber.org/zap v1.21.0
+	gopkg.in/natefinch/lumberjack.v2 v2.2.1
+)
+
+require (
+	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
+	go.uber.org/atomic v1.7.0 // indirect
+	go.uber.org/multierr v1.6.0 // indirect
+	golang.org/x/sys v0.0.0-20210510120138-977fb7262007 // indirect
 )
@@ -0,0 +1,29 @@
+module github.com/brinick/logging/grpc
+
+go 1.21
+
+require (
+	github.com/brinick/logging v0.1.0
+	google.golang.org/grpc v1.45.0
+)
+
+require (
+	github.com/brinick/fs v0.0.0-20200323111627-1a7de91c34e8 // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
+	github.com/sirupsen/logrus v1.4.2 // indirect
+	go.uber.org/atomic v1.7.0 // indirect
+	go.uber.org/multierr v1.6.0 // indirect
+	go.uber.org/zap v1.21.0 // indirect
+	golang.org/x/net v0.0.0-20210405180319-a5a99cb37ef4 // indirect
+	golang.org/x/sys v0.0.0-20210510120138-977fb7262007 // indirect
+	golang.org/x/text v0.3.3 // indirect
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013 // indirect
+	google.golang.org/protobuf v1.26.0 // indirect
+	gopkg.in/natefinch/lumberjack.v2 v2.2.1 // indirect
+)
+
+// The logging module is built from the parent directory of this one. The
+// version required, used by the modules depending on this one, must be a
+// published tag of the logging module: see RELEASING.md.
+replace github.com/brinick/logging => ../
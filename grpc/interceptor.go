@@ -0,0 +1,70 @@
+// Package grpc provides gRPC server interceptors which log each call
+// through a logging.Logger. It is a module of its own, so that the
+// logging package itself does not depend on gRPC.
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/brinick/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor returns an interceptor logging each unary call
+// with its method, status code and duration, at info level if it
+// succeeded and at error level otherwise. If the given logger is nil,
+// the package-level logger is used.
+func UnaryServerInterceptor(l logging.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(l, "finished unary call", info.FullMethod, err, time.Since(start))
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns an interceptor logging each streaming
+// call as for UnaryServerInterceptor, once the stream has finished
+func StreamServerInterceptor(l logging.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(l, "finished streaming call", info.FullMethod, err, time.Since(start))
+		return err
+	}
+}
+
+// logCall logs the outcome of a call to the given logger,
+// or to the package-level logger if it is nil
+func logCall(l logging.Logger, msg, method string, err error, d time.Duration) {
+	code := status.Code(err)
+	fields := []logging.Field{
+		logging.Str("grpc.method", method),
+		logging.Str("grpc.code", code.String()),
+		logging.Dur("grpc.duration", d),
+	}
+
+	switch {
+	case code == codes.OK && l != nil:
+		l.Info(msg, fields...)
+	case code == codes.OK:
+		logging.Info(msg, fields...)
+	case l != nil:
+		l.Error(msg, append(fields, logging.ErrField(err))...)
+	default:
+		logging.Error(msg, append(fields, logging.ErrField(err))...)
+	}
+}
@@ -0,0 +1,113 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brinick/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newMemoryLogger(t *testing.T) *logging.MemoryLogger {
+	t.Helper()
+
+	l, err := logging.NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+// checkCall checks the entry logged for a call
+func checkCall(t *testing.T, l *logging.MemoryLogger, level, method, code string) logging.Entry {
+	t.Helper()
+
+	entry, ok := l.LastEntry()
+	if !ok {
+		t.Fatal("nothing logged for the call")
+	}
+	if entry.Level != level {
+		t.Errorf("got level %s, want %s", entry.Level, level)
+	}
+	if got, _ := entry.Field("grpc.method"); got != method {
+		t.Errorf("got grpc.method %v, want %s", got, method)
+	}
+	if got, _ := entry.Field("grpc.code"); got != code {
+		t.Errorf("got grpc.code %v, want %s", got, code)
+	}
+	if got, _ := entry.Field("grpc.duration"); got.(time.Duration) <= 0 {
+		t.Errorf("got grpc.duration %v, want a positive duration", got)
+	}
+	return entry
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	l := newMemoryLogger(t)
+	intercept := UnaryServerInterceptor(l)
+	info := &grpc.UnaryServerInfo{FullMethod: "/users.Users/Get"}
+
+	resp, err := intercept(context.Background(), "req", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		time.Sleep(time.Millisecond)
+		return "resp", nil
+	})
+	if resp != "resp" || err != nil {
+		t.Errorf("got %v, %v, want the handler result", resp, err)
+	}
+	checkCall(t, l, "info", "/users.Users/Get", "OK")
+
+	failure := status.Error(codes.NotFound, "no such user")
+	_, err = intercept(context.Background(), "req", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, failure
+	})
+	if err != failure {
+		t.Errorf("got error %v, want the handler error", err)
+	}
+	entry := checkCall(t, l, "error", "/users.Users/Get", "NotFound")
+	if got, _ := entry.Field("err"); got != failure {
+		t.Errorf("got err field %v, want %v", got, failure)
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	l := newMemoryLogger(t)
+	intercept := StreamServerInterceptor(l)
+	info := &grpc.StreamServerInfo{FullMethod: "/users.Users/List"}
+
+	err := intercept(nil, nil, info, func(srv interface{}, ss grpc.ServerStream) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCall(t, l, "info", "/users.Users/List", "OK")
+
+	// Errors without a gRPC status are reported as Unknown
+	err = intercept(nil, nil, info, func(srv interface{}, ss grpc.ServerStream) error {
+		return errors.New("stream broken")
+	})
+	if err == nil {
+		t.Fatal("got no error, want the handler error")
+	}
+	checkCall(t, l, "error", "/users.Users/List", "Unknown")
+}
+
+func TestPackageLevelFallback(t *testing.T) {
+	if err := logging.SetClient("memory", nil); err != nil {
+		t.Fatal(err)
+	}
+	l := logging.Client().(*logging.MemoryLogger)
+
+	intercept := UnaryServerInterceptor(nil)
+	info := &grpc.UnaryServerInfo{FullMethod: "/users.Users/Get"}
+	intercept(context.Background(), "req", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		time.Sleep(time.Millisecond)
+		return nil, status.Error(codes.Internal, "boom")
+	})
+
+	checkCall(t, l, "error", "/users.Users/Get", "Internal")
+}
@@ -0,0 +1,22 @@
+package logging
+
+import "time"
+
+// StartHeartbeat logs the given message and fields at info level on every
+// tick of the given interval, via whichever package-level logger is set at
+// the time, until the returned stop function is called. Calling stop waits
+// for the background goroutine to exit. A non-positive interval logs
+// nothing.
+func StartHeartbeat(interval time.Duration, msg string, fields ...Field) (stop func()) {
+	return runEvery(interval, func() {
+		client().Info(msg, fields...)
+	})
+}
+
+// StartHeartbeatFor is as StartHeartbeat, logging via l rather than
+// the package-level logger
+func StartHeartbeatFor(l LogLeveler, interval time.Duration, msg string, fields ...Field) (stop func()) {
+	return runEvery(interval, func() {
+		l.Info(msg, fields...)
+	})
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestStartHeartbeat(t *testing.T) {
+	l := useMemoryClient(t)
+	before := runtime.NumGoroutine()
+
+	stop := StartHeartbeat(5*time.Millisecond, "alive", Str("service", "api"))
+	deadline := time.Now().Add(time.Second)
+	for len(l.Entries()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	stop()
+
+	entries := l.Entries()
+	if len(entries) < 2 {
+		t.Fatalf("got %d heartbeats within a second, want at least 2", len(entries))
+	}
+	for _, e := range entries {
+		if e.Level != "info" || e.Msg != "alive" {
+			t.Errorf("got %s %q, want info \"alive\"", e.Level, e.Msg)
+		}
+		if got, _ := e.Field("service"); got != "api" {
+			t.Errorf("got service field %v, want api", got)
+		}
+	}
+
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("got %d goroutines after stop, want at most %d", n, before)
+	}
+
+	l.Reset()
+	time.Sleep(20 * time.Millisecond)
+	if n := len(l.Entries()); n != 0 {
+		t.Errorf("got %d heartbeats after stop, want none", n)
+	}
+	stop()
+}
+
+func TestStartHeartbeatFor(t *testing.T) {
+	pkg := useMemoryClient(t)
+	l, err := NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := StartHeartbeatFor(l, 5*time.Millisecond, "alive")
+	waitForEntry(t, l, "alive")
+	stop()
+
+	if n := len(pkg.Entries()); n != 0 {
+		t.Errorf("got %d heartbeats via the package-level logger, want none", n)
+	}
+}
+
+func TestStartHeartbeatNonPositiveInterval(t *testing.T) {
+	l := useMemoryClient(t)
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		stop := StartHeartbeat(interval, "alive")
+		time.Sleep(20 * time.Millisecond)
+		stop()
+	}
+
+	if n := len(l.Entries()); n != 0 {
+		t.Errorf("got %d heartbeats, want none", n)
+	}
+}
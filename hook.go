@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"sort"
+	"strings"
+)
+
+// Hook is implemented by types wanting to be notified of log entries,
+// for side effects such as forwarding errors to an alerting service.
+// Fire is called for each entry logged at one of the Levels, with the
+// fields as they are output. Name identifies the hook, for HasHook.
+type Hook interface {
+	Name() string
+	Levels() []string
+	Fire(level string, msg string, fields []Field) error
+}
+
+// registeredHook is a Hook along with the set of levels it fires for
+type registeredHook struct {
+	hook   Hook
+	levels map[string]bool
+}
+
+// AddHook registers a hook to be fired for every subsequent entry
+// logged at one of its levels
+func (p *processor) AddHook(h Hook) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.hooks = append(p.hooks, registeredHook{h, hookLevels(h)})
+}
+
+// HasHook reports if a hook with the given name has been registered,
+// for registering a hook only once
+func (p *processor) HasHook(name string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, rh := range p.hooks {
+		if rh.hook.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fire calls each hook registered for the given level, with the fields
+// de-duplicated and sorted by name as for the logrus backend, returning
+// the first error raised by any of them
+func (p *processor) fire(level, msg string, fields []Field) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var (
+		output   []Field
+		firstErr error
+	)
+	for _, rh := range p.hooks {
+		if !rh.levels[level] {
+			continue
+		}
+
+		if output == nil {
+			data := mapify(fields...)
+			output = fieldsOf(data)
+			releaseMap(data)
+		}
+
+		if err := rh.hook.Fire(level, msg, output); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// hookLevels returns the set of canonical level names the hook fires
+// for. Unknown level names are ignored.
+func hookLevels(h Hook) map[string]bool {
+	levels := map[string]bool{}
+	for _, name := range h.Levels() {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == levelFatal {
+			levels[name] = true
+			continue
+		}
+
+		if lvl, err := parseLevel(name); err == nil {
+			levels[lvl] = true
+		}
+	}
+	return levels
+}
+
+// fieldsOf converts a map of field values back into a slice of Fields,
+// sorted by name
+func fieldsOf(data map[string]interface{}) []Field {
+	fields := make([]Field, 0, len(data))
+	for name, val := range data {
+		fields = append(fields, F(name, val))
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Name < fields[j].Name
+	})
+	return fields
+}
@@ -0,0 +1,152 @@
+package logging
+
+import (
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+// recordingHook records the entries it is fired for
+type recordingHook struct {
+	name   string
+	levels []string
+	err    error
+	fired  []Entry
+}
+
+func (h *recordingHook) Name() string     { return h.name }
+func (h *recordingHook) Levels() []string { return h.levels }
+
+func (h *recordingHook) Fire(level, msg string, fields []Field) error {
+	h.fired = append(h.fired, Entry{Level: level, Msg: msg, Fields: fields})
+	return h.err
+}
+
+func TestHasHook(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog", "memory", "none"} {
+		t.Run(name, func(t *testing.T) {
+			l, err := NewClient(name, &Config{Writer: ioutil.Discard})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if l.HasHook("alerts") {
+				t.Fatal("HasHook: got true before registering")
+			}
+
+			l.AddHook(&recordingHook{name: "alerts", levels: []string{"error"}})
+			if want := name != "none"; l.HasHook("alerts") != want {
+				t.Errorf("HasHook: got %v after registering, want %v", !want, want)
+			}
+
+			if l.HasHook("other") {
+				t.Error("HasHook: got true for another name")
+			}
+
+			if want := name != "none"; l.WithFields(Str("a", "b")).HasHook("alerts") != want {
+				t.Errorf("HasHook on a child logger: want %v", want)
+			}
+		})
+	}
+}
+
+func TestMultiLoggerHasHook(t *testing.T) {
+	a, _ := NewMemoryLogger(nil)
+	b, _ := NewMemoryLogger(nil)
+	a.AddHook(&recordingHook{name: "alerts"})
+
+	if !NewMultiLogger(a, b).HasHook("alerts") {
+		t.Error("HasHook: got false with the hook on one logger")
+	}
+}
+
+func TestHookFiring(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog", "memory"} {
+		t.Run(name, func(t *testing.T) {
+			l, err := NewClient(name, &Config{LogLevel: "trace", Writer: ioutil.Discard})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			h := &recordingHook{name: "h", levels: []string{"warning", "error"}, err: errors.New("boom")}
+			l.AddHook(h)
+
+			l.Debug("debug")
+			l.Info("info")
+			l.Warn("warn", Str("b", "2"), Str("a", "1"))
+			if err := l.TryError("error"); err == nil || err.Error() != "boom" {
+				t.Errorf("TryError: got %v, want the hook error", err)
+			}
+
+			if len(h.fired) != 2 {
+				t.Fatalf("fired %d times, want 2", len(h.fired))
+			}
+			if h.fired[0].Level != "warn" || h.fired[1].Level != "error" {
+				t.Errorf("fired at %s and %s, want warn and error", h.fired[0].Level, h.fired[1].Level)
+			}
+
+			want := []Field{Str("a", "1"), Str("b", "2")}
+			if got := h.fired[0].Fields; !reflect.DeepEqual(got[:2], want) {
+				t.Errorf("got fields %v, want %v first", got, want)
+			}
+		})
+	}
+}
+
+// panicHook panics whenever it is fired
+type panicHook struct{}
+
+func (panicHook) Name() string     { return "panic" }
+func (panicHook) Levels() []string { return []string{"trace", "debug", "info", "warn", "error"} }
+
+func (panicHook) Fire(level, msg string, fields []Field) error {
+	panic("hook exploded")
+}
+
+func TestPanicSafe(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog", "memory"} {
+		t.Run(name, func(t *testing.T) {
+			l, err := NewClient(name, &Config{Writer: ioutil.Discard, PanicSafe: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.AddHook(panicHook{})
+			l.AddEnricher(func(fields []Field) []Field {
+				if len(fields) > 0 && fields[0].Name == "explode" {
+					panic("enricher exploded")
+				}
+				return fields
+			})
+
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Fatalf("the panic escaped: %v", r)
+					}
+				}()
+				l.Error("survives")
+				l.InfoL([]string{"one", "two"})
+				if err := l.TryWarn("survives too"); err != nil {
+					t.Errorf("TryWarn: %v", err)
+				}
+				l.Info("survives the enricher", Str("explode", "yes"))
+			}()
+		})
+	}
+
+	t.Run("unsafe", func(t *testing.T) {
+		l, err := NewMemoryLogger(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.AddHook(panicHook{})
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected the panic to escape without PanicSafe")
+			}
+		}()
+		l.Error("panics")
+	})
+}
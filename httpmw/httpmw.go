@@ -0,0 +1,203 @@
+// Package httpmw provides an HTTP middleware which logs each request
+// through a logging.Logger
+package httpmw
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/brinick/logging"
+)
+
+// CorrelationHeader is the header carrying the ID correlating
+// a request across logs, traces and metrics
+const CorrelationHeader = "X-Correlation-ID"
+
+// maxCorrelationIDLen is the length above which a correlation ID
+// taken from a request is replaced by a generated one
+const maxCorrelationIDLen = 128
+
+// loggerKey is the request context key of the request logger
+type loggerKey struct{}
+
+// Middleware returns a middleware logging each request once it has been
+// served, with its method, path, status, response size and duration.
+// Requests are logged at info level, or at warn or error level for client
+// or server error statuses respectively. The fields are named as expected
+// by the apache output format.
+//
+// The correlation ID of each request is taken from its X-Correlation-ID
+// header, or generated if it has none, or one longer than 128 bytes or
+// with anything but printable ASCII other than spaces, in which case the
+// header is set on the request for the handlers. The ID is returned in the
+// response header, and logged with WithCorrelationID, under the key set by
+// the CorrelationKey of the logger configuration.
+//
+// Each request is given a child logger carrying its method, path and
+// correlation ID, which the handlers can retrieve with FromContext. If
+// the given logger is nil, the package-level logger is used.
+func Middleware(l logging.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			id := r.Header.Get(CorrelationHeader)
+			if !validCorrelationID(id) {
+				id = newCorrelationID()
+				r.Header.Set(CorrelationHeader, id)
+			}
+			w.Header().Set(CorrelationHeader, id)
+
+			lggr := l
+			if lggr == nil {
+				lggr = logging.Client()
+			}
+
+			if lggr != nil {
+				reqLogger := lggr.WithCorrelationID(id).WithFields(
+					logging.Str("method", r.Method),
+					logging.Str("path", r.URL.Path),
+				)
+				r = r.WithContext(context.WithValue(r.Context(), loggerKey{}, reqLogger))
+			}
+
+			rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			logRequest(lggr, r, rec, id, time.Since(start))
+		})
+	}
+}
+
+// FromContext returns the request logger attached to the
+// context by Middleware, or nil if there is none
+func FromContext(ctx context.Context) logging.Logger {
+	l, _ := ctx.Value(loggerKey{}).(logging.Logger)
+	return l
+}
+
+// validCorrelationID reports if the correlation ID taken from a request
+// may be logged and returned as is: it must be non-empty, at most
+// maxCorrelationIDLen long, and only printable ASCII without spaces, so
+// that it cannot inject lines or escapes into the logs
+func validCorrelationID(id string) bool {
+	if id == "" || len(id) > maxCorrelationIDLen {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// randRead fills the given slice with random bytes,
+// as replaced by the tests to simulate a failure
+var randRead = rand.Read
+
+// fallbackIDs counts the correlation IDs generated without randomness
+var fallbackIDs uint64
+
+// newCorrelationID returns a random 128-bit ID, hex encoded. Should no
+// random bytes be available, the ID is made of the current time and a
+// counter instead, both hex encoded, which is unique to the process.
+func newCorrelationID() string {
+	b := make([]byte, 16)
+	if _, err := randRead(b); err != nil {
+		n := atomic.AddUint64(&fallbackIDs, 1)
+		return strconv.FormatInt(time.Now().UnixNano(), 16) + "-" + strconv.FormatUint(n, 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// logRequest logs the served request to the given logger,
+// or to the package-level logger if it is nil
+func logRequest(l logging.Logger, r *http.Request, rec *recorder, id string, d time.Duration) {
+	fields := []logging.Field{
+		logging.Str("remote", r.RemoteAddr),
+		logging.Str("method", r.Method),
+		logging.Str("path", r.URL.Path),
+		logging.Str("proto", r.Proto),
+		logging.Int("bytes", rec.size),
+		logging.Str("referer", r.Referer()),
+		logging.Str("user_agent", r.UserAgent()),
+		logging.Dur("duration", d),
+	}
+
+	if l == nil {
+		logging.WithCorrelationID(id).LogStatus(rec.status, "served request", fields...)
+		return
+	}
+	l.WithCorrelationID(id).LogStatus(rec.status, "served request", fields...)
+}
+
+// recorder wraps a ResponseWriter to record the
+// status and size of the response written to it
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	wroteHeader bool
+}
+
+// WriteHeader records the status before writing it
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the number of bytes written
+func (r *recorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(p)
+	r.size += n
+	return n, err
+}
+
+// Flush sends any buffered data to the client,
+// if the wrapped ResponseWriter supports it
+func (r *recorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, if the wrapped
+// ResponseWriter supports it, for websockets for example. The status
+// is recorded as 101 Switching Protocols, as the response is then
+// written by the handler directly to the connection.
+func (r *recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("httpmw: %T does not support hijacking", r.ResponseWriter)
+	}
+
+	conn, rw, err := h.Hijack()
+	if err == nil && !r.wroteHeader {
+		r.status = http.StatusSwitchingProtocols
+		r.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Push initiates an HTTP/2 server push, if the wrapped
+// ResponseWriter supports it
+func (r *recorder) Push(target string, opts *http.PushOptions) error {
+	if p, ok := r.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
@@ -0,0 +1,272 @@
+package httpmw
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/brinick/logging"
+)
+
+func newMemoryLogger(t *testing.T) *logging.MemoryLogger {
+	t.Helper()
+
+	l, err := logging.NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestMiddlewareStatusLevel(t *testing.T) {
+	tests := []struct {
+		status int
+		level  string
+	}{
+		{http.StatusOK, "info"},
+		{http.StatusNotFound, "warn"},
+		{http.StatusInternalServerError, "error"},
+	}
+
+	for _, tt := range tests {
+		l := newMemoryLogger(t)
+		handler := Middleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte("body"))
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/path", nil))
+
+		entry, ok := l.LastEntry()
+		if !ok {
+			t.Fatalf("status %d: nothing logged", tt.status)
+		}
+
+		if entry.Level != tt.level || entry.Msg != "served request" {
+			t.Errorf("status %d: got %s %q", tt.status, entry.Level, entry.Msg)
+		}
+
+		if got, _ := entry.Field("status"); got != tt.status {
+			t.Errorf("status %d: got status field %v", tt.status, got)
+		}
+
+		if got, _ := entry.Field("bytes"); got != 4 {
+			t.Errorf("status %d: got bytes field %v, want 4", tt.status, got)
+		}
+	}
+}
+
+func TestMiddlewareCorrelationID(t *testing.T) {
+	l, err := logging.NewMemoryLogger(&logging.Config{CorrelationKey: "trace_ref"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var handlerID interface{}
+	handler := Middleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("handling")
+		entry, _ := l.LastEntry()
+		handlerID, _ = entry.Field("trace_ref")
+	}))
+
+	t.Run("extracted", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/path", nil)
+		req.Header.Set(CorrelationHeader, "abc-123")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		entry, _ := l.LastEntry()
+		if got, _ := entry.Field("trace_ref"); got != "abc-123" {
+			t.Errorf("got request log correlation ID %v, want abc-123", got)
+		}
+		if handlerID != "abc-123" {
+			t.Errorf("got handler log correlation ID %v, want abc-123", handlerID)
+		}
+		if got := rec.Header().Get(CorrelationHeader); got != "abc-123" {
+			t.Errorf("got response header %q, want abc-123", got)
+		}
+	})
+
+	t.Run("generated", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/path", nil))
+
+		id := rec.Header().Get(CorrelationHeader)
+		if len(id) != 32 {
+			t.Fatalf("got generated correlation ID %q, want 32 hex digits", id)
+		}
+
+		entry, _ := l.LastEntry()
+		if got, _ := entry.Field("trace_ref"); got != id {
+			t.Errorf("got request log correlation ID %v, want %s", got, id)
+		}
+		if handlerID != id {
+			t.Errorf("got handler log correlation ID %v, want %s", handlerID, id)
+		}
+
+		rec2 := httptest.NewRecorder()
+		handler.ServeHTTP(rec2, httptest.NewRequest("GET", "/path", nil))
+		if rec2.Header().Get(CorrelationHeader) == id {
+			t.Error("the same correlation ID was generated twice")
+		}
+	})
+
+	t.Run("replaced", func(t *testing.T) {
+		for _, bad := range []string{"forged\nlevel=error", strings.Repeat("a", 129)} {
+			var requestID string
+			replacing := Middleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestID = r.Header.Get(CorrelationHeader)
+				FromContext(r.Context()).Info("handling")
+				entry, _ := l.LastEntry()
+				handlerID, _ = entry.Field("trace_ref")
+			}))
+
+			req := httptest.NewRequest("GET", "/path", nil)
+			req.Header[CorrelationHeader] = []string{bad}
+			rec := httptest.NewRecorder()
+			replacing.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(CorrelationHeader)
+			if len(id) != 32 {
+				t.Fatalf("got response header %q for %q, want a generated ID", id, bad)
+			}
+			if requestID != id {
+				t.Errorf("got request header %q, want %s", requestID, id)
+			}
+			if handlerID != id {
+				t.Errorf("got handler log correlation ID %v, want %s", handlerID, id)
+			}
+			entry, _ := l.LastEntry()
+			if got, _ := entry.Field("trace_ref"); got != id {
+				t.Errorf("got request log correlation ID %v, want %s", got, id)
+			}
+		}
+	})
+}
+
+func TestMiddlewareHijack(t *testing.T) {
+	l := newMemoryLogger(t)
+	handler := Middleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, ok := w.(http.Hijacker)
+		if !ok {
+			t.Error("the ResponseWriter is not an http.Hijacker")
+			return
+		}
+
+		conn, rw, err := h.Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\nhello\n")
+		rw.Flush()
+	}))
+
+	served := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+		close(served)
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("got status %d, want 101", resp.StatusCode)
+	}
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || strings.TrimSpace(line) != "hello" {
+		t.Errorf("got %q (%v) from the hijacked connection, want hello", line, err)
+	}
+
+	<-served
+	entry, ok := l.LastEntry()
+	if !ok {
+		t.Fatal("nothing logged")
+	}
+	if got, _ := entry.Field("status"); got != http.StatusSwitchingProtocols {
+		t.Errorf("got status field %v, want 101", got)
+	}
+}
+
+// pushRecorder is a ResponseRecorder supporting HTTP/2 server push
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (p *pushRecorder) Push(target string, opts *http.PushOptions) error {
+	p.pushed = append(p.pushed, target)
+	return nil
+}
+
+func TestMiddlewarePush(t *testing.T) {
+	var pushErr error
+	handler := Middleware(newMemoryLogger(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := w.(http.Pusher)
+		if !ok {
+			t.Fatal("the ResponseWriter is not an http.Pusher")
+		}
+		pushErr = p.Push("/style.css", nil)
+	}))
+
+	w := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if pushErr != nil || len(w.pushed) != 1 || w.pushed[0] != "/style.css" {
+		t.Errorf("got pushed %v (%v), want [/style.css]", w.pushed, pushErr)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if pushErr != http.ErrNotSupported {
+		t.Errorf("got %v pushing without support, want %v", pushErr, http.ErrNotSupported)
+	}
+}
+
+func TestValidCorrelationID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"abc-123", true},
+		{strings.Repeat("a", maxCorrelationIDLen), true},
+		{"", false},
+		{strings.Repeat("a", maxCorrelationIDLen+1), false},
+		{"abc 123", false},
+		{"abc\n123", false},
+		{"abc\x1b[31m", false},
+		{"abc\x7f", false},
+		{"abcé", false},
+	}
+
+	for _, tt := range tests {
+		if got := validCorrelationID(tt.id); got != tt.want {
+			t.Errorf("got %v for %q, want %v", got, tt.id, tt.want)
+		}
+	}
+}
+
+func TestNewCorrelationIDWithoutRandomness(t *testing.T) {
+	saved := randRead
+	randRead = func([]byte) (int, error) { return 0, errors.New("no randomness") }
+	defer func() { randRead = saved }()
+
+	first, second := newCorrelationID(), newCorrelationID()
+	if first == second {
+		t.Errorf("got the same correlation ID %q twice", first)
+	}
+	for _, id := range []string{first, second} {
+		if !validCorrelationID(id) {
+			t.Errorf("got invalid correlation ID %q", id)
+		}
+	}
+}
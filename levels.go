@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Canonical level names, shared by all backends
+const (
+	levelTrace = "trace"
+	levelDebug = "debug"
+	levelInfo  = "info"
+	levelWarn  = "warn"
+	levelError = "error"
+	levelFatal = "fatal"
+)
+
+// parseLevel validates the given level name, as found in Config.LogLevel,
+// and returns its canonical form
+func parseLevel(name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	switch name {
+	case levelTrace, levelDebug, levelInfo, levelError:
+		return name, nil
+	case levelWarn, "warning":
+		return levelWarn, nil
+	default:
+		if len(name) == 0 {
+			return "", fmt.Errorf(
+				"please provide a log level. Legal values: trace, debug, info, warn, error",
+			)
+		}
+
+		return "", fmt.Errorf(
+			"unknown log level: %s. Legal values: trace, debug, info, warn, error",
+			name,
+		)
+	}
+}
+
+// levelRanks ranks the canonical level names, lowest first
+var levelRanks = map[string]int{
+	levelTrace: 0,
+	levelDebug: 1,
+	levelInfo:  2,
+	levelWarn:  3,
+	levelError: 4,
+	levelFatal: 5,
+}
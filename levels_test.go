@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestOnLevelChange(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog", "memory"} {
+		t.Run(name, func(t *testing.T) {
+			l, err := NewClient(name, &Config{LogLevel: "info", Writer: ioutil.Discard})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var changes [][2]string
+			l.OnLevelChange(func(old, new string) {
+				changes = append(changes, [2]string{old, new})
+			})
+
+			steps := []string{"debug", "debug", "warning", "warn", "info"}
+			for _, level := range steps {
+				if err := l.SetLevel(level); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := l.SetLevel("bogus"); err == nil {
+				t.Error("SetLevel: expected an error for an unknown level")
+			}
+
+			want := [][2]string{{"info", "debug"}, {"debug", "warn"}, {"warn", "info"}}
+			if len(changes) != len(want) {
+				t.Fatalf("got changes %v, want %v", changes, want)
+			}
+			for i := range want {
+				if changes[i] != want[i] {
+					t.Errorf("change %d: got %v, want %v", i, changes[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"trace", "trace", true},
+		{" debug ", "debug", true},
+		{"warning", "warn", true},
+		{"error", "error", true},
+		{"fatal", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := parseLevel(tt.name)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseLevel(%q): got %q, %v", tt.name, got, err)
+		}
+	}
+}
+
+func TestTraceLevel(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := NewClient(name, &Config{LogLevel: "debug", OutFormat: "json", Writer: &buf})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			l.Trace("hidden")
+			if buf.Len() != 0 {
+				t.Errorf("got %q at the debug level, want the trace entry dropped", buf.String())
+			}
+
+			if err := l.SetLevel("trace"); err != nil {
+				t.Fatal(err)
+			}
+			l.Trace("shown")
+			if !strings.Contains(buf.String(), "shown") {
+				t.Errorf("got %q at the trace level, want the trace entry", buf.String())
+			}
+		})
+	}
+}
+
+func TestIsEnabled(t *testing.T) {
+	levels := []string{"trace", "debug", "info", "warn", "error"}
+
+	for _, name := range []string{"logrus", "zap", "slog", "memory"} {
+		for i, configured := range levels {
+			l, err := NewClient(name, &Config{LogLevel: configured, Writer: ioutil.Discard})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for j, level := range levels {
+				if got, want := l.IsEnabled(level), j >= i; got != want {
+					t.Errorf("%s at %s: got IsEnabled(%s) %v, want %v", name, configured, level, got, want)
+				}
+			}
+			if l.IsEnabled("warning") != l.IsEnabled("warn") || l.IsEnabled("bogus") {
+				t.Errorf("%s at %s: got warning unlike warn, or an unknown level enabled", name, configured)
+			}
+		}
+	}
+
+	if (&NullLogger{}).IsEnabled("error") {
+		t.Error("got error enabled for the NullLogger")
+	}
+}
@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logfmtFormatter is a logrus formatter emitting entries as strict
+// logfmt: time, level and msg followed by the fields, sorted by name,
+// as key=value pairs with values quoted where necessary
+type logfmtFormatter struct {
+	timeFormat       string
+	disableTimestamp bool
+}
+
+// Format renders a single log entry as a logfmt line
+func (f *logfmtFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var b bytes.Buffer
+
+	if !f.disableTimestamp {
+		timeFormat := f.timeFormat
+		if timeFormat == "" {
+			timeFormat = time.RFC3339
+		}
+		writeLogfmtPair(&b, "time", entry.Time.Format(timeFormat))
+	}
+
+	writeLogfmtPair(&b, "level", logrusLevelName(entry.Level))
+	writeLogfmtPair(&b, "msg", entry.Message)
+
+	names := make([]string, 0, len(entry.Data))
+	for name := range entry.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		writeLogfmtPair(&b, name, logfmtValue(entry.Data[name]))
+	}
+
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// writeLogfmtPair appends a key=value pair, quoting the value if needed
+func writeLogfmtPair(b *bytes.Buffer, key, val string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+
+	b.WriteString(key)
+	b.WriteByte('=')
+	if logfmtNeedsQuoting(val) {
+		b.WriteString(strconv.Quote(val))
+	} else {
+		b.WriteString(val)
+	}
+}
+
+// logfmtValue returns the string form of a field value
+func logfmtValue(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// logfmtNeedsQuoting reports if the value is empty, or contains
+// spaces, quotes, equals signs or control characters
+func logfmtNeedsQuoting(val string) bool {
+	if val == "" {
+		return true
+	}
+
+	return strings.IndexFunc(val, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == 0x7f
+	}) >= 0
+}
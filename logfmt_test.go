@@ -0,0 +1,33 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLogfmtQuoting(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := NewLogrusLogger(&Config{
+		Writer:           &buf,
+		OutFormat:        "logfmt",
+		DisableTimestamp: true,
+		ReportCaller:     new(bool),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Info("two words",
+		Str("plain", "value"),
+		Str("spaced", "a b"),
+		Str("quote", `say "hi"`),
+		Str("equals", "a=b"),
+		Str("empty", ""),
+		Int("n", 3),
+	)
+
+	want := `level=info msg="two words" empty="" equals="a=b" n=3 plain=value quote="say \"hi\"" spaced="a b"` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
@@ -1,46 +1,320 @@
 package logging
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Logger defines the interface for logging clients
 type Logger interface {
 	Name() string
 	Path() string
+	WithFields(...Field) Logger
+	Named(string) Logger
+	WithCorrelationID(string) Logger
+	WithContext(context.Context) Logger
+	AddEnricher(Enricher)
+	AddContextEnricher(ContextEnricher)
+	AddFilter(Filter)
+	AddHook(Hook)
+	HasHook(string) bool
+	SetLevel(string) error
+	GetLevel() string
+	OnLevelChange(func(old, new string))
+	IsEnabled(string) bool
+	SetLevelDefaultFields(string, ...Field) error
+	ToAlso(io.Writer) LogLeveler
+	Writer(string) io.Writer
+	LogEvery(time.Duration, string, string, ...Field)
+	LogStatus(int, string, ...Field)
+	Pipeline() []string
+	Flush() error
+	Close() error
+	Quieter
 	Configurer
 	LogLeveler
+	TryLogLeveler
+	CtxLogLeveler
+	Printer
 }
 
+// Enricher is a function that may add, modify or remove fields
+// from a log entry before it is formatted. Enrichers are chained,
+// each one receiving the fields returned by the previous one.
+type Enricher func([]Field) []Field
+
+// Filter is a function run on every log entry after the enrichers, which
+// returns the message and fields to log in place of the given ones, or
+// false to drop the entry. Filters are chained in the order registered.
+type Filter func(level, msg string, fields []Field) (string, []Field, bool)
+
 // Configurer defines the interface to configure logging clients
 type Configurer interface {
 	Configure(*Config) error
 }
 
+// Quieter defines the interface to temporarily mute non-critical logging
+type Quieter interface {
+	Quiet(time.Duration)
+	Unquiet()
+}
+
 // LogLeveler defines the interface for log level methods
 type LogLeveler interface {
+	Trace(string, ...Field)
+	TraceL([]string, ...Field)
 	Debug(string, ...Field)
 	DebugL([]string, ...Field)
 	Info(string, ...Field)
 	InfoL([]string, ...Field)
+	Warn(string, ...Field)
+	WarnL([]string, ...Field)
 	Error(string, ...Field)
 	ErrorL([]string, ...Field)
 	Fatal(string, ...Field)
 	FatalL([]string, ...Field)
 }
 
+// Printer defines the interface for unstructured logging in the style
+// of the standard log package, at the level given by Config.PrintLevel
+type Printer interface {
+	Printf(string, ...interface{})
+	Println(...interface{})
+}
+
+// TryLogLeveler defines the interface for log level methods that report
+// whether the entry was successfully written, for critical paths which
+// need to know. There is no fatal variant, as fatal exits regardless.
+type TryLogLeveler interface {
+	TryTrace(string, ...Field) error
+	TryDebug(string, ...Field) error
+	TryInfo(string, ...Field) error
+	TryWarn(string, ...Field) error
+	TryError(string, ...Field) error
+}
+
 // Config is the concrete type that is passed to a Configurer
 type Config struct {
-	LogLevel  string // Debug | Info | Error
-	OutFormat string // json | text
-	Outfile   string // path to file. Missing = send to stdout/err
+	LogLevel  string `json:"log_level" yaml:"log_level"`   // Trace | Debug | Info | Warn (or Warning) | Error
+	OutFormat string `json:"out_format" yaml:"out_format"` // json | text | logfmt | ecs | apache
+	Outfile   string `json:"outfile" yaml:"outfile"`       // path to file. Missing = send to stdout/err
+
+	// TimeFormat is the layout, as for time.Format, used for timestamps in
+	// json and text output. Defaults to "2006-01-02 15:04:05" for text.
+	TimeFormat string `json:"time_format" yaml:"time_format"`
+
+	// DisableTimestamp omits the timestamp from json and text output, e.g.
+	// when running under journald, which adds its own
+	DisableTimestamp bool `json:"disable_timestamp" yaml:"disable_timestamp"`
+
+	// UTC renders timestamps in UTC rather than in the local time zone
+	UTC bool `json:"utc" yaml:"utc"`
+
+	// ForceColors and DisableColors override the terminal detection used
+	// by the logrus text format to decide whether to colour its output.
+	// At most one of them may be set.
+	ForceColors   bool `json:"force_colors" yaml:"force_colors"`
+	DisableColors bool `json:"disable_colors" yaml:"disable_colors"`
+
+	// FieldKeyMsg, FieldKeyLevel and FieldKeyTime rename the message,
+	// level and time keys of the json and text formats, which are msg,
+	// level and time if empty
+	FieldKeyMsg   string `json:"field_key_msg" yaml:"field_key_msg"`
+	FieldKeyLevel string `json:"field_key_level" yaml:"field_key_level"`
+	FieldKeyTime  string `json:"field_key_time" yaml:"field_key_time"`
+
+	// EpochTime emits the time in the json format as a numeric Unix
+	// timestamp, in seconds or, if EpochMillis is also set, milliseconds.
+	// It is ignored by the other formats.
+	EpochTime   bool `json:"epoch_time" yaml:"epoch_time"`
+	EpochMillis bool `json:"epoch_millis" yaml:"epoch_millis"`
+
+	// PrettyJSON indents the json output over multiple lines, for reading
+	// during local debugging (logrus only). It is an error to set it with
+	// any other OutFormat.
+	PrettyJSON bool `json:"pretty_json" yaml:"pretty_json"`
+
+	// Writer, if set, is written to instead of Outfile or Stream, for
+	// example to capture output in a bytes.Buffer. It is not closed by
+	// the logger.
+	Writer io.Writer `json:"-" yaml:"-"`
+
+	// Stream is the standard stream written to if there is no Outfile,
+	// or if AlsoStdout is set: stdout (the default) or stderr
+	Stream string `json:"stream" yaml:"stream"`
+
+	// AlsoStdout sends output to Stream as well as to Outfile, if set
+	AlsoStdout bool `json:"also_stdout" yaml:"also_stdout"`
+
+	// SplitStreams sends entries at error level and above to stderr, and
+	// the others to stdout, whatever the Stream. If there is an Outfile,
+	// entries are written both to it and to the streams.
+	SplitStreams bool `json:"split_streams" yaml:"split_streams"`
+
+	// Async hands formatted lines over to a background goroutine to be
+	// written, buffering up to BufferSize lines (default 1024) before
+	// logging blocks. Flush or Close the logger to write out the buffered
+	// lines: note that any still buffered when the program crashes are
+	// lost, and that write errors are reported to stderr rather than
+	// returned. Logging at error level or above waits for the lines
+	// buffered so far, including its own, to be written.
+	Async      bool `json:"async" yaml:"async"`
+	BufferSize int  `json:"buffer_size" yaml:"buffer_size"`
+
+	// MaxBufferMemoryMB, if positive, caps the memory held by the lines
+	// buffered for Async output and the entry held open by DedupWindow.
+	// Once the cap is reached, the oldest buffered lines are dropped to
+	// make room for new ones, and the number dropped is reported to stderr.
+	MaxBufferMemoryMB int `json:"max_buffer_memory_mb" yaml:"max_buffer_memory_mb"`
+
+	// Syslog, if set, also sends entries to syslog (logrus only)
+	Syslog *SyslogConfig `json:"syslog" yaml:"syslog"`
+
+	// Outfile rotation. If any of these are set, the outfile is rotated
+	// once it reaches MaxSizeMB megabytes (default 100), keeping at most
+	// MaxBackups old files (default all) no older than MaxAgeDays days
+	// (default no age limit).
+	MaxSizeMB  int `json:"max_size_mb" yaml:"max_size_mb"`
+	MaxBackups int `json:"max_backups" yaml:"max_backups"`
+	MaxAgeDays int `json:"max_age_days" yaml:"max_age_days"`
+
+	// CreateDirs creates the Outfile parent directory, and any missing
+	// parents, if it does not exist rather than returning an error
+	CreateDirs bool `json:"create_dirs" yaml:"create_dirs"`
+
+	// FileMode is the permission bits of a newly created Outfile (default
+	// 0664), and DirMode those of any directories created for it (default
+	// 0755). Both are subject to the process umask.
+	FileMode os.FileMode `json:"file_mode" yaml:"file_mode"`
+	DirMode  os.FileMode `json:"dir_mode" yaml:"dir_mode"`
+
+	// Truncate empties any existing Outfile when it is opened, rather
+	// than appending to it. Note that it is reopened, and so emptied
+	// again, each time the logger is configured.
+	Truncate bool `json:"truncate" yaml:"truncate"`
+
+	// OmitUnknownSource drops the caller fields entirely when the
+	// caller cannot be determined, rather than emitting ??? placeholders
+	OmitUnknownSource bool `json:"omit_unknown_source" yaml:"omit_unknown_source"`
+
+	// ReportCaller adds the func, file and line fields of the caller to
+	// every entry.
+	// It defaults to true if nil: set it to false to save the cost of
+	// looking up the caller on every entry.
+	ReportCaller *bool `json:"report_caller" yaml:"report_caller"`
+
+	// CallerSkip is the number of extra frames to skip when looking up the
+	// caller, for wrapper libraries which want their own caller reported
+	CallerSkip int `json:"caller_skip" yaml:"caller_skip"`
+
+	// CombinedSource reports the caller in the pkg and src fields, with
+	// src combining the function name and line (e.g. "main:42"), as done
+	// before the separate func, file and line fields were introduced
+	CombinedSource bool `json:"combined_source" yaml:"combined_source"`
+
+	// GlobalFields are added to every entry, for example to identify
+	// the service. Per-level default, bound and per-call fields of the
+	// same name take priority.
+	GlobalFields []Field `json:"global_fields" yaml:"global_fields"`
+
+	// ExpandErrors adds, for each error field, the messages of the errors it
+	// wraps in a <name>.causes field, and its %+v form, if that gives more
+	// detail such as a stack trace, in a <name>.detail field
+	ExpandErrors bool `json:"expand_errors" yaml:"expand_errors"`
+
+	// MaskKeys partially masks the values of fields with the given
+	// names, keeping only the prefix/suffix defined by the MaskSpec
+	MaskKeys map[string]MaskSpec `json:"mask_keys" yaml:"mask_keys"`
+
+	// RedactPatterns are regular expressions matched against every field
+	// value, whatever its name, with any matching parts replaced by ***
+	RedactPatterns []string `json:"redact_patterns" yaml:"redact_patterns"`
+
+	// LinePrefixTimestamp prepends an RFC3339 timestamp to every line,
+	// for consumers that expect one regardless of format. Note that in
+	// json mode this means lines are no longer pure JSON.
+	LinePrefixTimestamp bool `json:"line_prefix_timestamp" yaml:"line_prefix_timestamp"`
+
+	// MaxFieldDepth, if positive, limits how deeply nested map, slice
+	// and struct field values are rendered, replacing deeper levels
+	// with <truncated>
+	MaxFieldDepth int `json:"max_field_depth" yaml:"max_field_depth"`
+
+	// MaxFieldLen and MaxMsgLen, if positive, truncate field values and
+	// messages longer than the given number of characters, appending an
+	// ellipsis and the original length. Field values are measured in
+	// their fmt.Sprint form, and replaced by it if truncated.
+	MaxFieldLen int `json:"max_field_len" yaml:"max_field_len"`
+	MaxMsgLen   int `json:"max_msg_len" yaml:"max_msg_len"`
+
+	// Sampling, if set, throttles repeated entries of the same level
+	// and message, as described by SamplingConfig
+	Sampling *SamplingConfig `json:"sampling" yaml:"sampling"`
+
+	// ComponentLevels sets the level of the loggers returned by Named for
+	// the given component names, in place of the LogLevel. It may be lower
+	// than the LogLevel, to debug a single component for example.
+	ComponentLevels map[string]string `json:"component_levels" yaml:"component_levels"`
+
+	// PrintLevel is the level at which Printf and Println log: trace,
+	// debug, info (the default), warn or error
+	PrintLevel string `json:"print_level" yaml:"print_level"`
+
+	// CorrelationKey is the name of the field added by WithCorrelationID,
+	// for the ID correlating entries across logs, traces and metrics.
+	// It defaults to correlation_id.
+	CorrelationKey string `json:"correlation_key" yaml:"correlation_key"`
+
+	// ExitOnFatal makes the Fatal methods exit the program, with status 1,
+	// after logging. It defaults to true if nil: set it to false for the
+	// Fatal methods to return instead, for example in libraries and tests.
+	ExitOnFatal *bool `json:"exit_on_fatal" yaml:"exit_on_fatal"`
+
+	// DedupWindow, if positive, suppresses entries repeating the level and
+	// message of the one logged just before, within the given duration of
+	// it. A summary line "<msg> repeated N times" is logged once a
+	// different entry arrives or the window closes.
+	DedupWindow time.Duration `json:"dedup_window" yaml:"dedup_window"`
+
+	// PanicSafe recovers from any panic raised while emitting a log line
+	// (e.g. in a hook, enricher or formatter), dropping the line rather
+	// than letting the panic take down the application
+	PanicSafe bool `json:"panic_safe" yaml:"panic_safe"`
+}
+
+// defaultConfig returns the defaults of the logrus, zap and slog loggers,
+// filled in by their constructors for any field not set
+func defaultConfig() *Config {
+	return &Config{
+		OutFormat: "text",
+		LogLevel:  "info",
+	}
+}
+
+// checkedConfig fills in the given defaults for the fields not set in
+// cfg, which may be nil, and validates the result, as is done before
+// configuring a logger
+func checkedConfig(defaults, cfg *Config) (*Config, error) {
+	cfg = defaults.Update(cfg)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
 }
 
 // Update will overwrite this Config's fields with the provided one
-// if the new fields are not the zero value for that field.
+// if the new fields are not the zero value for that field. Use
+// UpdateFields to reset fields to their zero value.
 func (c *Config) Update(cfg *Config) *Config {
 	if cfg != nil {
 		if cfg.LogLevel != "" {
@@ -54,10 +328,323 @@ func (c *Config) Update(cfg *Config) *Config {
 		if cfg.Outfile != "" {
 			c.Outfile = cfg.Outfile
 		}
+
+		if cfg.FieldKeyMsg != "" {
+			c.FieldKeyMsg = cfg.FieldKeyMsg
+		}
+
+		if cfg.FieldKeyLevel != "" {
+			c.FieldKeyLevel = cfg.FieldKeyLevel
+		}
+
+		if cfg.FieldKeyTime != "" {
+			c.FieldKeyTime = cfg.FieldKeyTime
+		}
+
+		if cfg.EpochTime {
+			c.EpochTime = true
+		}
+
+		if cfg.EpochMillis {
+			c.EpochMillis = true
+		}
+
+		if cfg.PrettyJSON {
+			c.PrettyJSON = true
+		}
+
+		if cfg.Writer != nil {
+			c.Writer = cfg.Writer
+		}
+
+		if cfg.Stream != "" {
+			c.Stream = cfg.Stream
+		}
+
+		if cfg.TimeFormat != "" {
+			c.TimeFormat = cfg.TimeFormat
+		}
+
+		if cfg.DisableTimestamp {
+			c.DisableTimestamp = true
+		}
+
+		if cfg.UTC {
+			c.UTC = true
+		}
+
+		if cfg.ForceColors {
+			c.ForceColors = true
+		}
+
+		if cfg.DisableColors {
+			c.DisableColors = true
+		}
+
+		if cfg.AlsoStdout {
+			c.AlsoStdout = true
+		}
+
+		if cfg.SplitStreams {
+			c.SplitStreams = true
+		}
+
+		if cfg.Async {
+			c.Async = true
+		}
+
+		if cfg.BufferSize != 0 {
+			c.BufferSize = cfg.BufferSize
+		}
+
+		if cfg.MaxBufferMemoryMB != 0 {
+			c.MaxBufferMemoryMB = cfg.MaxBufferMemoryMB
+		}
+
+		if cfg.Syslog != nil {
+			c.Syslog = cfg.Syslog
+		}
+
+		if cfg.MaxSizeMB != 0 {
+			c.MaxSizeMB = cfg.MaxSizeMB
+		}
+
+		if cfg.MaxBackups != 0 {
+			c.MaxBackups = cfg.MaxBackups
+		}
+
+		if cfg.MaxAgeDays != 0 {
+			c.MaxAgeDays = cfg.MaxAgeDays
+		}
+
+		if cfg.CreateDirs {
+			c.CreateDirs = true
+		}
+
+		if cfg.FileMode != 0 {
+			c.FileMode = cfg.FileMode
+		}
+
+		if cfg.DirMode != 0 {
+			c.DirMode = cfg.DirMode
+		}
+
+		if cfg.Truncate {
+			c.Truncate = true
+		}
+
+		if cfg.OmitUnknownSource {
+			c.OmitUnknownSource = true
+		}
+
+		if cfg.ReportCaller != nil {
+			c.ReportCaller = cfg.ReportCaller
+		}
+
+		if cfg.CallerSkip != 0 {
+			c.CallerSkip = cfg.CallerSkip
+		}
+
+		if cfg.CombinedSource {
+			c.CombinedSource = true
+		}
+
+		if len(cfg.GlobalFields) > 0 {
+			c.GlobalFields = cfg.GlobalFields
+		}
+
+		if cfg.ExpandErrors {
+			c.ExpandErrors = true
+		}
+
+		if cfg.MaskKeys != nil {
+			c.MaskKeys = cfg.MaskKeys
+		}
+
+		if cfg.RedactPatterns != nil {
+			c.RedactPatterns = cfg.RedactPatterns
+		}
+
+		if cfg.LinePrefixTimestamp {
+			c.LinePrefixTimestamp = true
+		}
+
+		if cfg.MaxFieldDepth != 0 {
+			c.MaxFieldDepth = cfg.MaxFieldDepth
+		}
+
+		if cfg.MaxFieldLen != 0 {
+			c.MaxFieldLen = cfg.MaxFieldLen
+		}
+
+		if cfg.MaxMsgLen != 0 {
+			c.MaxMsgLen = cfg.MaxMsgLen
+		}
+
+		if cfg.Sampling != nil {
+			c.Sampling = cfg.Sampling
+		}
+
+		if cfg.ComponentLevels != nil {
+			c.ComponentLevels = cfg.ComponentLevels
+		}
+
+		if cfg.PrintLevel != "" {
+			c.PrintLevel = cfg.PrintLevel
+		}
+
+		if cfg.CorrelationKey != "" {
+			c.CorrelationKey = cfg.CorrelationKey
+		}
+
+		if cfg.ExitOnFatal != nil {
+			c.ExitOnFatal = cfg.ExitOnFatal
+		}
+
+		if cfg.DedupWindow != 0 {
+			c.DedupWindow = cfg.DedupWindow
+		}
+
+		if cfg.PanicSafe {
+			c.PanicSafe = true
+		}
 	}
 	return c
 }
 
+// UpdateFields overwrites the named fields of this Config with those of
+// the provided one, whatever their value, so that fields may be cleared as
+// well as set. For example, to log to stdout again rather than to a file:
+//
+//	cfg.UpdateFields(&Config{}, "Outfile")
+//
+// A nil Config is treated as the zero Config. An error is returned,
+// with no fields updated, if any name is not that of a Config field.
+func (c *Config) UpdateFields(cfg *Config, names ...string) error {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	dst := reflect.ValueOf(c).Elem()
+	src := reflect.ValueOf(cfg).Elem()
+	for _, name := range names {
+		if !dst.FieldByName(name).IsValid() {
+			return fmt.Errorf("unknown Config field: %s", name)
+		}
+	}
+
+	for _, name := range names {
+		dst.FieldByName(name).Set(src.FieldByName(name))
+	}
+	return nil
+}
+
+// Validate checks the Config independently of any logger, so that
+// configuration errors may be reported before setting one up. It checks
+// that LogLevel is legal, OutFormat is one of json, text, logfmt, ecs or
+// apache, the parent directory of any Outfile exists (unless CreateDirs is
+// set), and that the remaining fields with restricted values are legal too.
+// Note that not every backend supports every format.
+func (c *Config) Validate() error {
+	if _, err := parseLevel(c.LogLevel); err != nil {
+		return err
+	}
+
+	switch c.OutFormat {
+	case "json", "text", "logfmt", "ecs", "apache":
+	default:
+		return fmt.Errorf(
+			"unknown formatter %s. Legal: json | text | logfmt | ecs | apache",
+			c.OutFormat,
+		)
+	}
+
+	if outfile := strings.TrimSpace(c.Outfile); outfile != "" && c.Writer == nil && !c.CreateDirs {
+		if err := logfileCheck(outfile); err != nil {
+			return err
+		}
+	}
+
+	if _, err := openStream(c.Stream); err != nil {
+		return err
+	}
+
+	for component, level := range c.ComponentLevels {
+		if _, err := parseLevel(level); err != nil {
+			return fmt.Errorf("component %s: %v", component, err)
+		}
+	}
+
+	if c.PrintLevel != "" {
+		if _, err := parseLevel(c.PrintLevel); err != nil {
+			return err
+		}
+	}
+
+	if c.ForceColors && c.DisableColors {
+		return fmt.Errorf("ForceColors and DisableColors cannot both be set")
+	}
+
+	if c.PrettyJSON && c.OutFormat != "json" {
+		return fmt.Errorf("PrettyJSON requires the json OutFormat, not %s", c.OutFormat)
+	}
+
+	if c.MaxBufferMemoryMB < 0 {
+		return fmt.Errorf("MaxBufferMemoryMB cannot be negative, not %d", c.MaxBufferMemoryMB)
+	}
+
+	if _, err := redactValues(c.RedactPatterns); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LoadConfig reads a Config from the json file at the given path, keyed
+// by the snake_case names of the fields (e.g. log_level, out_format,
+// outfile). Unknown keys are an error. The Config is validated with the
+// default LogLevel and OutFormat filled in if missing, as done by the
+// constructors, but is returned without them so that it may be passed
+// on to any constructor and get its defaults.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read logging config: %v", err)
+	}
+
+	cfg := &Config{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse logging config %s: %v", path, err)
+	}
+
+	if err := defaultConfig().Update(cfg).Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// rotates reports if any outfile rotation setting is given
+func (c *Config) rotates() bool {
+	return c.MaxSizeMB > 0 || c.MaxBackups > 0 || c.MaxAgeDays > 0
+}
+
+// fileMode returns the permission bits to create the outfile with
+func (c *Config) fileMode() os.FileMode {
+	if c.FileMode == 0 {
+		return 0664
+	}
+	return c.FileMode
+}
+
+// dirMode returns the permission bits to create outfile directories with
+func (c *Config) dirMode() os.FileMode {
+	if c.DirMode == 0 {
+		return 0755
+	}
+	return c.DirMode
+}
+
 // F is a shortcut for creating logging Fields
 func F(name string, val interface{}) Field {
 	return Field{
@@ -66,24 +653,96 @@ func F(name string, val interface{}) Field {
 	}
 }
 
-// Field represents a logging Field
+// Fields converts a map of names to values into Fields, to pass on with
+// ... to the logging functions. The order of the returned Fields is
+// unspecified, as with map iteration.
+func Fields(m map[string]interface{}) []Field {
+	fields := make([]Field, 0, len(m))
+	for name, val := range m {
+		fields = append(fields, F(name, val))
+	}
+	return fields
+}
+
+// Str creates a Field with a string value
+func Str(name string, val string) Field {
+	return F(name, val)
+}
+
+// Int creates a Field with an int value
+func Int(name string, val int) Field {
+	return F(name, val)
+}
+
+// Bool creates a Field with a bool value
+func Bool(name string, val bool) Field {
+	return F(name, val)
+}
+
+// Float creates a Field with a float64 value
+func Float(name string, val float64) Field {
+	return F(name, val)
+}
+
+// Dur creates a Field with a time.Duration value
+func Dur(name string, val time.Duration) Field {
+	return F(name, val)
+}
+
+// Time creates a Field with a time.Time value
+func Time(name string, val time.Time) Field {
+	return F(name, val)
+}
+
+// Lazy creates a Field whose value is computed by calling fn only if
+// the entry is actually emitted, to save the cost of computing values
+// at levels which are not logged. Note that fn may be called once for
+// each logger of a MultiLogger.
+func Lazy(name string, fn func() interface{}) Field {
+	return F(name, lazyValue(fn))
+}
+
+// lazyValue is the value of a Field created by Lazy
+type lazyValue func() interface{}
+
+// Field represents a logging Field. A field named as one of the keys the
+// output format uses for the timestamp, level or message (e.g. msg) is
+// output as fields.<name>, rather than clashing with it.
 type Field struct {
-	Name string
-	Val  interface{}
+	Name string      `json:"name" yaml:"name"`
+	Val  interface{} `json:"val" yaml:"val"`
 }
 
+// errFieldName is the name of the field added by ErrField
+const errFieldName = "err"
+
 var (
-	// logger is the logging package log client set via the SetClient function
-	logger Logger
+	// logger is the logging package log client set via the SetClient function.
+	// It is guarded by loggerMu, and read via the Client function.
+	logger   Logger
+	loggerMu sync.RWMutex
 
-	// ErrField is a shortcut function for adding an error field to the log output
+	// defaulted is set when logger is the default set by client,
+	// rather than one requested via SetClient
+	defaulted bool
+
+	// ErrField is a shortcut function for adding an error field to the log
+	// output. A nil error gives an empty Field, which is not output.
 	ErrField = func(e error) Field {
-		return F("err", e)
+		if e == nil {
+			return Field{}
+		}
+		return F(errFieldName, e)
 	}
 )
 
-// NewClient returns a new instance of the concrete
-// logging Client with the given name
+// clientNames are the names of the logging clients
+// understood by NewClient and SetClient
+var clientNames = []string{"logrus", "zap", "slog", "memory", "none"}
+
+// NewClient returns a new instance of the concrete logging Client with
+// the given name, one of logrus, zap, slog, memory or none. The name none is the
+// way to intentionally disable logging: any other name is an error.
 func NewClient(name string, cfg *Config) (Logger, error) {
 	var (
 		logger Logger
@@ -93,8 +752,20 @@ func NewClient(name string, cfg *Config) (Logger, error) {
 	switch name {
 	case "logrus":
 		logger, err = NewLogrusLogger(cfg)
-	default:
+	case "zap":
+		logger, err = NewZapLogger(cfg)
+	case "slog":
+		logger, err = newSlogClient(cfg)
+	case "memory":
+		logger, err = NewMemoryLogger(cfg)
+	case "none":
 		logger, err = NewNullLogger(cfg)
+	default:
+		return nil, fmt.Errorf(
+			"unknown logging client type %s. Legal: %s",
+			name,
+			strings.Join(clientNames, ", "),
+		)
 	}
 
 	if err != nil {
@@ -106,49 +777,93 @@ func NewClient(name string, cfg *Config) (Logger, error) {
 // SetClient is a factory function to initiate the logging client
 // with the given name. The instance is then set at the package level,
 // and is retrievable in other packages using the Client() function.
+// If the package-level logger already has the given name, it is
+// reconfigured with the given Config instead, unless that is nil.
+// An unknown name is an error, as for NewClient.
 func SetClient(name string, cfg *Config) error {
-	if logger != nil && logger.Name() == name {
-		return nil
-	}
+	_, err := SetClientGet(name, cfg)
+	return err
+}
 
-	var (
-		lggr Logger
-		err  error
-	)
+// SetClientGet sets the package-level logger as for SetClient, and
+// returns it, for attaching hooks or deriving child loggers straight away
+func SetClientGet(name string, cfg *Config) (Logger, error) {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
 
-	switch name {
-	case "logrus":
-		lggr, err = NewLogrusLogger(cfg)
-	case "none":
-		lggr, err = NewNullLogger(cfg)
-	default:
-		panic(
-			fmt.Sprintf(
-				"unknown logging client type %s. Legal: %s",
-				name,
-				strings.Join([]string{"logrus", "none"}, ", "),
-			),
-		)
+	if logger != nil && !defaulted && logger.Name() == name {
+		if cfg == nil {
+			return logger, nil
+		}
+
+		if err := reconfigure(logger, cfg); err != nil {
+			return nil, err
+		}
+		return logger, nil
 	}
 
+	lggr, err := NewClient(name, cfg)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Go ahead and set the package-level logger
 	logger = lggr
-	return nil
+	defaulted = false
+	return logger, nil
+}
+
+// reconfigure configures the existing logger with the given Config,
+// after filling in its defaults and validating it as its constructor
+// would have done
+func reconfigure(l Logger, cfg *Config) error {
+	defaults := defaultConfig()
+	if l.Name() == "memory" {
+		defaults = defaultMemoryConfig()
+	}
+
+	cfg, err := checkedConfig(defaults, cfg)
+	if err != nil {
+		return err
+	}
+	return l.Configure(cfg)
 }
 
 // Client returns the logging client, or nil if it has not
-// been initiated yet.
+// been initiated yet. It is safe to call concurrently with SetClient.
 func Client() Logger {
+	loggerMu.RLock()
+	defer loggerMu.RUnlock()
+	return logger
+}
+
+// client returns the package-level logger for use by the short cut
+// functions. If SetClient has not yet been called, the logger is first
+// set to a default logrus logger, writing text at info level to stdout.
+func client() Logger {
+	if l := Client(); l != nil {
+		return l
+	}
+
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+
+	if logger == nil {
+		lggr, err := NewLogrusLogger(nil)
+		if err != nil {
+			// The default config is valid, but be safe
+			logger = &NullLogger{}
+		} else {
+			logger = lggr
+		}
+		defaulted = true
+	}
 	return logger
 }
 
 // Configure will configure the logger with the given attributes
 func Configure(level, format, outfile string) {
-	logger.Configure(&Config{
+	client().Configure(&Config{
 		LogLevel:  level,
 		OutFormat: format,
 		Outfile:   outfile,
@@ -156,57 +871,319 @@ func Configure(level, format, outfile string) {
 }
 
 // ------------------------------------------------------------------
-// Short cuts to the logging client
+// Short cuts to the logging client. If no client has been set
+// with SetClient, a default logrus client is used (see client).
 // ------------------------------------------------------------------
 
+// Trace calls the logger Trace method
+func Trace(msg string, fields ...Field) {
+	client().Trace(msg, fields...)
+}
+
 // Debug calls the logger Debug method
 func Debug(msg string, fields ...Field) {
-	logger.Debug(msg, fields...)
+	client().Debug(msg, fields...)
 }
 
 // Info calls the logger Info method
 func Info(msg string, fields ...Field) {
-	logger.Info(msg, fields...)
+	client().Info(msg, fields...)
+}
+
+// Warn calls the logger Warn method
+func Warn(msg string, fields ...Field) {
+	client().Warn(msg, fields...)
 }
 
 // Error calls the logger Error method
 func Error(msg string, fields ...Field) {
-	logger.Error(msg, fields...)
+	client().Error(msg, fields...)
 }
 
 // Fatal calls the logger Fatal method
 func Fatal(msg string, fields ...Field) {
-	logger.Fatal(msg, fields...)
+	client().Fatal(msg, fields...)
+}
+
+// TraceL calls the logger TraceL method
+func TraceL(msgs []string, fields ...Field) {
+	client().TraceL(msgs, fields...)
+}
+
+// DebugL calls the logger DebugL method
+func DebugL(msgs []string, fields ...Field) {
+	client().DebugL(msgs, fields...)
+}
+
+// InfoL calls the logger InfoL method
+func InfoL(msgs []string, fields ...Field) {
+	client().InfoL(msgs, fields...)
+}
+
+// WarnL calls the logger WarnL method
+func WarnL(msgs []string, fields ...Field) {
+	client().WarnL(msgs, fields...)
+}
+
+// ErrorL calls the logger ErrorL method
+func ErrorL(msgs []string, fields ...Field) {
+	client().ErrorL(msgs, fields...)
+}
+
+// FatalL calls the logger FatalL method
+func FatalL(msgs []string, fields ...Field) {
+	client().FatalL(msgs, fields...)
+}
+
+// WithFields calls the logger WithFields method, returning
+// a child logger carrying the given fields
+func WithFields(fields ...Field) Logger {
+	return client().WithFields(fields...)
+}
+
+// AddEnricher calls the logger AddEnricher method
+func AddEnricher(fn Enricher) {
+	client().AddEnricher(fn)
+}
+
+// AddContextEnricher calls the logger AddContextEnricher method
+func AddContextEnricher(fn ContextEnricher) {
+	client().AddContextEnricher(fn)
+}
+
+// WithContext calls the logger WithContext method, returning a
+// child logger carrying the fields taken from the context
+func WithContext(ctx context.Context) Logger {
+	return client().WithContext(ctx)
+}
+
+// TraceCtx calls the logger TraceCtx method
+func TraceCtx(ctx context.Context, msg string, fields ...Field) {
+	client().TraceCtx(ctx, msg, fields...)
+}
+
+// DebugCtx calls the logger DebugCtx method
+func DebugCtx(ctx context.Context, msg string, fields ...Field) {
+	client().DebugCtx(ctx, msg, fields...)
+}
+
+// InfoCtx calls the logger InfoCtx method
+func InfoCtx(ctx context.Context, msg string, fields ...Field) {
+	client().InfoCtx(ctx, msg, fields...)
+}
+
+// WarnCtx calls the logger WarnCtx method
+func WarnCtx(ctx context.Context, msg string, fields ...Field) {
+	client().WarnCtx(ctx, msg, fields...)
+}
+
+// ErrorCtx calls the logger ErrorCtx method
+func ErrorCtx(ctx context.Context, msg string, fields ...Field) {
+	client().ErrorCtx(ctx, msg, fields...)
+}
+
+// AddFilter calls the logger AddFilter method
+func AddFilter(fn Filter) {
+	client().AddFilter(fn)
+}
+
+// SetLevel calls the logger SetLevel method, changing the
+// level of the package-level logger in place
+func SetLevel(level string) error {
+	return client().SetLevel(level)
+}
+
+// IsEnabled calls the logger IsEnabled method, reporting
+// if an entry at the given level would currently be output
+func IsEnabled(level string) bool {
+	return client().IsEnabled(level)
+}
+
+// Named calls the logger Named method, returning a child
+// logger for the given component
+func Named(name string) Logger {
+	return client().Named(name)
+}
+
+// WithCorrelationID calls the logger WithCorrelationID method, returning
+// a child logger carrying the given correlation ID
+func WithCorrelationID(id string) Logger {
+	return client().WithCorrelationID(id)
+}
+
+// GetLevel calls the logger GetLevel method
+func GetLevel() string {
+	return client().GetLevel()
+}
+
+// SetLevelDefaultFields calls the logger SetLevelDefaultFields method
+func SetLevelDefaultFields(level string, fields ...Field) error {
+	return client().SetLevelDefaultFields(level, fields...)
+}
+
+// ToAlso calls the logger ToAlso method, returning a logger whose
+// calls write to the given writer in addition to the usual output
+func ToAlso(w io.Writer) LogLeveler {
+	return client().ToAlso(w)
+}
+
+// Pipeline calls the logger Pipeline method
+func Pipeline() []string {
+	return client().Pipeline()
+}
+
+// Flush calls the Flush method of the package-level logger, if any
+func Flush() error {
+	if l := Client(); l != nil {
+		return l.Flush()
+	}
+	return nil
+}
+
+// Close calls the Close method of the package-level logger, if any
+func Close() error {
+	if l := Client(); l != nil {
+		return l.Close()
+	}
+	return nil
+}
+
+// Quiet calls the logger Quiet method, suppressing all
+// log lines below error level for the given duration
+func Quiet(d time.Duration) {
+	client().Quiet(d)
+}
+
+// Unquiet calls the logger Unquiet method
+func Unquiet() {
+	client().Unquiet()
+}
+
+// Printf calls the logger Printf method
+func Printf(format string, args ...interface{}) {
+	client().Printf(format, args...)
+}
+
+// Println calls the logger Println method
+func Println(args ...interface{}) {
+	client().Println(args...)
+}
+
+// LogEvery calls the logger LogEvery method
+func LogEvery(d time.Duration, level, msg string, fields ...Field) {
+	client().LogEvery(d, level, msg, fields...)
+}
+
+// LogStatus calls the logger LogStatus method
+func LogStatus(status int, msg string, fields ...Field) {
+	client().LogStatus(status, msg, fields...)
+}
+
+// LogError logs the given message at error level along with the error
+// field, if err is non-nil, and returns err unchanged. A nil error is
+// passed through without logging anything.
+func LogError(err error, msg string, fields ...Field) error {
+	if err != nil {
+		client().Error(msg, append(fields, ErrField(err))...)
+	}
+	return err
 }
 
 // ------------------------------------------------------------------
 
-// source will return the line/lineno that called the
-// given logging level function
-func source() []Field {
-	// source will return the line/lineno that caulled the
-	// given logging level function
-	// Who called the logging function.
-	// As this function is called from the logger,
-	// we need to go up 2 frames to get to the caller
-	// of the logger function
+// pkgPrefix prefixes the names of the functions of this package
+var pkgPrefix = reflect.TypeOf(Field{}).PkgPath() + "."
+
+// stdlogPrefix prefixes the names of the functions of the standard
+// library log package, which are skipped as for those of this package
+// so that lines logged via StdLogger report the original caller
+const stdlogPrefix = "log."
+
+// callers fills pcs with the program counters of the calling goroutine,
+// as runtime.Callers, which tests replace to make the lookup fail
+var callers = runtime.Callers
+
+// source returns the func, file and line fields of the caller of
+// the given logging level function. If the caller cannot be determined,
+// placeholder values are returned unless omitUnknown is set, in which
+// case no fields are returned at all. The given number of extra frames
+// beyond the caller are skipped.
+func source(omitUnknown bool, skip int) []Field {
+	frame, ok := callerFrame(skip)
+	if !ok {
+		if omitUnknown {
+			return nil
+		}
+		frame = runtime.Frame{Function: "???", File: "???"}
+	}
+
+	return []Field{
+		Field{"func", frame.Function},
+		Field{"file", filepath.Base(frame.File)},
+		Field{"line", frame.Line},
+	}
+}
+
+// combinedSource returns the caller as the pkg and src fields,
+// as for source, with src combining the function name and line
+func combinedSource(omitUnknown bool, skip int) []Field {
 	var (
 		pkg = "???"
 		src = "???:0"
 	)
 
-	pc, _, lineno, ok := runtime.Caller(3)
-	if ok {
-		caller := runtime.FuncForPC(pc).Name()
-		path := filepath.Dir(caller)
-		base := filepath.Base(caller)
+	if frame, ok := callerFrame(skip); ok {
+		path := filepath.Dir(frame.Function)
+		base := filepath.Base(frame.Function)
 		srcToks := strings.SplitN(base, ".", 2)
 
 		pkg = filepath.Join(path, srcToks[0])
-		src = fmt.Sprintf("%s:%d", srcToks[1], lineno)
+		src = fmt.Sprintf("%s:%d", srcToks[1], frame.Line)
+	} else if omitUnknown {
+		return nil
 	}
+
 	return []Field{
 		Field{"pkg", pkg},
 		Field{"src", src},
 	}
 }
+
+// callerFrame returns the frame of the function that called into this
+// package to log, whichever route it took: the package-level shortcuts,
+// a Logger directly, a child logger, or the standard library log
+// package via StdLogger. Entries logged by this package
+// itself from a background goroutine (e.g. the heartbeat) are reported
+// against the outermost function of the package. The given number of
+// frames beyond the caller are skipped.
+func callerFrame(skip int) (runtime.Frame, bool) {
+	var pcs [32]uintptr
+
+	// Skip runtime.Callers, callerFrame and the source function
+	n := callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var (
+		last  runtime.Frame
+		found bool
+	)
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, pkgPrefix) &&
+			!strings.HasPrefix(frame.Function, stdlogPrefix) {
+			if frame.Function != "" && frame.Function != "runtime.goexit" {
+				if skip <= 0 {
+					return frame, true
+				}
+				skip--
+			}
+		} else {
+			last, found = frame, true
+		}
+
+		if !more {
+			break
+		}
+	}
+	return last, found
+}
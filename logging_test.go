@@ -0,0 +1,488 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// resetClient restores the package-level logger
+// to its initial state once the test is done
+func resetClient(t *testing.T) {
+	t.Helper()
+
+	loggerMu.Lock()
+	saved, savedDefaulted := logger, defaulted
+	logger, defaulted = nil, false
+	loggerMu.Unlock()
+
+	t.Cleanup(func() {
+		loggerMu.Lock()
+		defer loggerMu.Unlock()
+		logger, defaulted = saved, savedDefaulted
+	})
+}
+
+func TestSetClientReconfigureWhileLogging(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog", "memory"} {
+		t.Run(name, func(t *testing.T) {
+			resetClient(t)
+
+			if err := SetClient(name, &Config{Writer: ioutil.Discard}); err != nil {
+				t.Fatal(err)
+			}
+			child := Client().WithFields(Str("child", "yes"))
+
+			configs := []*Config{
+				{Writer: ioutil.Discard, LogLevel: "debug", OutFormat: "json", PanicSafe: true},
+				{Writer: ioutil.Discard, LogLevel: "warn", MaxMsgLen: 10, PrintLevel: "warn"},
+				{Writer: ioutil.Discard, LogLevel: "trace", MaskKeys: map[string]MaskSpec{"secret": {KeepPrefix: 1}}},
+			}
+
+			stop := make(chan struct{})
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						select {
+						case <-stop:
+							return
+						default:
+						}
+
+						Info("package level", Int("n", 1), Str("secret", "s"))
+						child.Debug("child", Str("k", "v"))
+						child.Warn("child warn")
+						Printf("printf %d", 2)
+					}
+				}()
+			}
+
+			deadline := time.Now().Add(200 * time.Millisecond)
+			for i := 0; time.Now().Before(deadline); i++ {
+				if err := SetClient(name, configs[i%len(configs)]); err != nil {
+					t.Error(err)
+					break
+				}
+			}
+
+			close(stop)
+			wg.Wait()
+		})
+	}
+}
+
+func TestSetClientSwitchWhileLogging(t *testing.T) {
+	resetClient(t)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				Info("package level", Int("n", 1))
+				Debug("debug")
+				Error("error", Str("k", "v"))
+				Client().WithFields(Str("child", "yes")).Warn("child")
+			}
+		}()
+	}
+
+	names := []string{"logrus", "zap", "slog", "memory"}
+	deadline := time.Now().Add(200 * time.Millisecond)
+	for i := 0; time.Now().Before(deadline); i++ {
+		if err := SetClient(names[i%len(names)], &Config{Writer: ioutil.Discard}); err != nil {
+			t.Error(err)
+			break
+		}
+	}
+
+	close(stop)
+	wg.Wait()
+}
+
+func TestDefaultClient(t *testing.T) {
+	resetClient(t)
+
+	out, err := ioutil.TempFile(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = out
+	t.Cleanup(func() { os.Stdout = stdout })
+
+	Debug("hidden")
+	Info("hello without SetClient")
+	out.Close()
+
+	data, err := ioutil.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); !strings.Contains(got, "hello without SetClient") || strings.Contains(got, "hidden") {
+		t.Errorf("got %q, want the info entry only", got)
+	}
+
+	if l := Client(); l.Name() != "logrus" || l.GetLevel() != "info" {
+		t.Errorf("got a %s default at level %s, want logrus at info", l.Name(), l.GetLevel())
+	}
+
+	// The default gives way to the client requested, even of the same name
+	if err := SetClient("logrus", &Config{Writer: ioutil.Discard, LogLevel: "debug"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := Client().GetLevel(); got != "debug" {
+		t.Errorf("got level %s after SetClient, want debug", got)
+	}
+}
+
+func TestSetClientSameNameReconfigures(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog", "memory"} {
+		t.Run(name, func(t *testing.T) {
+			resetClient(t)
+
+			if err := SetClient(name, &Config{Writer: ioutil.Discard, LogLevel: "info"}); err != nil {
+				t.Fatal(err)
+			}
+			first := Client()
+
+			if err := SetClient(name, &Config{Writer: ioutil.Discard, LogLevel: "debug"}); err != nil {
+				t.Fatal(err)
+			}
+			if Client() != first {
+				t.Error("got a new logger, want the existing one reconfigured")
+			}
+			if got := GetLevel(); got != "debug" {
+				t.Errorf("got level %s after the second SetClient, want debug", got)
+			}
+			if !IsEnabled("debug") {
+				t.Error("got debug disabled after the second SetClient")
+			}
+		})
+	}
+}
+
+func TestSetClientUnknownName(t *testing.T) {
+	resetClient(t)
+
+	_, want := NewClient("bogus", nil)
+	if want == nil {
+		t.Fatal("NewClient: expected an error for an unknown name")
+	}
+
+	l, err := SetClientGet("bogus", nil)
+	if err == nil || err.Error() != want.Error() {
+		t.Fatalf("SetClientGet: got error %v, want %v", err, want)
+	}
+	if l != nil || Client() != nil {
+		t.Errorf("SetClientGet: the client was set on error")
+	}
+}
+
+func TestWithCorrelationID(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"", "correlation_id"},
+		{"request_ref", "request_ref"},
+	}
+
+	for _, tt := range tests {
+		l, err := NewMemoryLogger(&Config{CorrelationKey: tt.key})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l.WithCorrelationID("id-1").Info("msg")
+		entry, _ := l.LastEntry()
+		if got, ok := entry.Field(tt.want); !ok || got != "id-1" {
+			t.Errorf("key %q: got %s field %v, want id-1", tt.key, tt.want, got)
+		}
+	}
+}
+
+func TestConfigUpdate(t *testing.T) {
+	cfg := &Config{LogLevel: "debug", OutFormat: "json", Outfile: "/tmp/app.log"}
+
+	cfg.Update(&Config{OutFormat: "text"})
+	if cfg.LogLevel != "debug" || cfg.OutFormat != "text" || cfg.Outfile != "/tmp/app.log" {
+		t.Errorf("got %+v after Update, want only OutFormat set", cfg)
+	}
+
+	// Zero values are ignored by Update, but not by UpdateFields
+	cfg.Update(&Config{})
+	if cfg.Outfile != "/tmp/app.log" {
+		t.Errorf("got Outfile %q after an empty Update, want it kept", cfg.Outfile)
+	}
+
+	if err := cfg.UpdateFields(&Config{LogLevel: "warn"}, "Outfile", "OutFormat", "LogLevel"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Outfile != "" || cfg.OutFormat != "" || cfg.LogLevel != "warn" {
+		t.Errorf("got %+v after UpdateFields, want Outfile and OutFormat cleared and LogLevel set", cfg)
+	}
+
+	if err := cfg.UpdateFields(nil, "LogLevel", "Bogus"); err == nil {
+		t.Error("UpdateFields: expected an error for an unknown field")
+	}
+	if cfg.LogLevel != "warn" {
+		t.Errorf("got LogLevel %q after a failed UpdateFields, want it unchanged", cfg.LogLevel)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing", "app.log")
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"level", Config{LogLevel: "loud"}, "loud"},
+		{"format", Config{OutFormat: "xml"}, "unknown formatter"},
+		{"outfile dir", Config{Outfile: missing}, "missing"},
+		{"stream", Config{Stream: "stdin"}, "stdin"},
+		{"component level", Config{ComponentLevels: map[string]string{"db": "loud"}}, "component db"},
+		{"colors", Config{ForceColors: true, DisableColors: true}, "ForceColors"},
+		{"pretty json", Config{PrettyJSON: true}, "PrettyJSON"},
+		{"buffer memory", Config{MaxBufferMemoryMB: -1}, "MaxBufferMemoryMB"},
+		{"redact pattern", Config{RedactPatterns: []string{"("}}, "redact pattern"},
+	}
+
+	seen := map[string]bool{}
+	for _, tt := range tests {
+		cfg := defaultConfig().Update(&tt.cfg)
+		err := cfg.Validate()
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %q, want it to mention %q", tt.name, err, tt.want)
+		}
+		if seen[err.Error()] {
+			t.Errorf("%s: got the same error as another failure: %q", tt.name, err)
+		}
+		seen[err.Error()] = true
+	}
+
+	// CreateDirs creates the missing directory, rather than failing
+	if err := defaultConfig().Update(&Config{Outfile: missing, CreateDirs: true}).Validate(); err != nil {
+		t.Errorf("got %v with CreateDirs, want no error", err)
+	}
+	if err := defaultConfig().Validate(); err != nil {
+		t.Errorf("got %v for the default Config, want no error", err)
+	}
+}
+
+func TestFieldsRoundTrip(t *testing.T) {
+	m := map[string]interface{}{"s": "v", "n": 1, "b": true, "nested": map[string]interface{}{"k": "v"}}
+
+	fields := Fields(m)
+	if len(fields) != len(m) {
+		t.Fatalf("got %d fields, want %d", len(fields), len(m))
+	}
+
+	data := mapify(fields...)
+	defer releaseMap(data)
+	if !reflect.DeepEqual(data, m) {
+		t.Errorf("got %v after the round trip, want %v", data, m)
+	}
+
+	if got := Fields(nil); len(got) != 0 {
+		t.Errorf("got %v for a nil map, want no fields", got)
+	}
+}
+
+func TestTypedFields(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		field Field
+		want  interface{}
+	}{
+		{Str("s", "v"), "v"},
+		{Int("i", 1), 1},
+		{Bool("b", true), true},
+		{Float("f", 1.5), 1.5},
+		{Dur("d", time.Second), time.Second},
+		{Time("t", now), now},
+	}
+
+	for _, tt := range tests {
+		if reflect.TypeOf(tt.field.Val) != reflect.TypeOf(tt.want) || tt.field.Val != tt.want {
+			t.Errorf("%s: got %#v, want %#v", tt.field.Name, tt.field.Val, tt.want)
+		}
+	}
+}
+
+func TestFatalWithoutExit(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		var buf bytes.Buffer
+		l, err := NewClient(name, &Config{Writer: &buf, OutFormat: "json", ExitOnFatal: new(bool)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l.Fatal("fatal but alive", Str("k", "v"))
+
+		var line map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+			t.Fatalf("%s: invalid json %q: %v", name, buf.String(), err)
+		}
+		if line["msg"] != "fatal but alive" || line["level"] != "fatal" {
+			t.Errorf("%s: got %v, want the fatal entry", name, line)
+		}
+	}
+}
+
+func TestPrintf(t *testing.T) {
+	l := useMemoryClient(t)
+
+	Printf("got %d items", 3)
+	Println("got", 4, "items")
+	l.Printf("method %s", "call")
+
+	want := []Entry{
+		{Level: "info", Msg: "got 3 items"},
+		{Level: "info", Msg: "got 4 items"},
+		{Level: "info", Msg: "method call"},
+	}
+	entries := l.Entries()
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i, e := range entries {
+		if e.Level != want[i].Level || e.Msg != want[i].Msg {
+			t.Errorf("entry %d: got %s %q, want %s %q", i, e.Level, e.Msg, want[i].Level, want[i].Msg)
+		}
+	}
+
+	printer, err := NewMemoryLogger(&Config{PrintLevel: "warn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	printer.Println("at the print level")
+	if e, _ := printer.LastEntry(); e.Level != "warn" {
+		t.Errorf("got level %s, want the warn PrintLevel", e.Level)
+	}
+}
+
+func TestPrintLevelInvalid(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog", "memory"} {
+		if _, err := NewClient(name, &Config{Writer: ioutil.Discard, PrintLevel: "fatal"}); err == nil {
+			t.Errorf("%s: got no error for the fatal PrintLevel", name)
+		}
+	}
+
+	l, err := NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Configure(&Config{LogLevel: "info", PrintLevel: "fatal"}); err == nil {
+		t.Error("Configure accepted the fatal PrintLevel")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	path := write("ok.json", `{"log_level": "debug", "out_format": "json", "mask_keys": {"password": {"keep_prefix": 1}}}`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LogLevel != "debug" || cfg.OutFormat != "json" || cfg.MaskKeys["password"].KeepPrefix != 1 {
+		t.Errorf("got %+v, want the file settings", cfg)
+	}
+
+	// The defaults are not filled in
+	cfg, err = LoadConfig(write("partial.json", `{"outfile": ""}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LogLevel != "" || cfg.OutFormat != "" {
+		t.Errorf("got LogLevel %q and OutFormat %q, want them left empty", cfg.LogLevel, cfg.OutFormat)
+	}
+
+	failures := map[string]string{
+		"unknown key": write("unknown.json", `{"loglevel": "debug"}`),
+		"bad json":    write("bad.json", `{"log_level": `),
+		"invalid":     write("invalid.json", `{"log_level": "loud"}`),
+		"missing":     filepath.Join(dir, "missing.json"),
+	}
+	for name, path := range failures {
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestConfigJSONRoundTrip(t *testing.T) {
+	exit := false
+	cfg := &Config{
+		LogLevel:        "debug",
+		OutFormat:       "json",
+		Outfile:         "/var/log/app.log",
+		TimeFormat:      time.RFC3339Nano,
+		ComponentLevels: map[string]string{"db": "warn"},
+		MaskKeys:        map[string]MaskSpec{"card": {KeepSuffix: 4}},
+		Sampling:        &SamplingConfig{Initial: 10, Thereafter: 100},
+		ExitOnFatal:     &exit,
+		DedupWindow:     time.Second,
+		Writer:          ioutil.Discard,
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys map[string]interface{}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"log_level", "out_format", "outfile", "component_levels", "mask_keys", "exit_on_fatal"} {
+		if _, ok := keys[key]; !ok {
+			t.Errorf("no %s key in %s", key, data)
+		}
+	}
+	if _, ok := keys["Writer"]; ok {
+		t.Errorf("got the Writer marshaled in %s", data)
+	}
+
+	got := &Config{}
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Writer = nil
+	if !reflect.DeepEqual(got, cfg) {
+		t.Errorf("got %+v after the round trip, want %+v", got, cfg)
+	}
+}
@@ -2,44 +2,79 @@ package logging
 
 import (
 	"fmt"
-	"os"
-	"strings"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/brinick/fs"
 	"github.com/sirupsen/logrus"
 )
 
 // ------------------------------------------------------------------
 
-func defaultLogrusConfig() *Config {
-	return &Config{
-		OutFormat: "text",
-		LogLevel:  "info",
-	}
-}
-
 // NewLogrusLogger wraps a logrus client
 func NewLogrusLogger(cfg *Config) (*LogrusLogger, error) {
-	l := &LogrusLogger{
-		log: logrus.New(),
-	}
-	if cfg == nil {
-		cfg = defaultLogrusConfig()
+	cfg, err := checkedConfig(defaultConfig(), cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := l.Configure(defaultLogrusConfig().Update(cfg)); err != nil {
+	l := newLogrusLogger(&logrusCore{
+		log:       logrus.New(),
+		processor: &processor{},
+	}, nil, "")
+
+	if err := l.Configure(cfg); err != nil {
 		return nil, err
 	}
 
 	return l, nil
 }
 
+// newLogrusLogger returns a logger on the given core,
+// binding the given fields and component
+func newLogrusLogger(core *logrusCore, bound []Field, component string) *LogrusLogger {
+	l := &LogrusLogger{logrusCore: core}
+	l.emitter = emitter{
+		proc:      core.processor,
+		backend:   l,
+		bound:     bound,
+		component: component,
+	}
+	return l
+}
+
 // ------------------------------------------------------------------
 
 // LogrusLogger defines a logger using the logrus package as its backend
 type LogrusLogger struct {
-	log  *logrus.Logger
-	path string
+	*logrusCore
+	emitter
+
+	// also, if set, is written each formatted line as well,
+	// as created by ToAlso
+	also io.Writer
+}
+
+// logrusCore holds the configuration and state of a LogrusLogger,
+// shared between a logger and the child loggers derived from it
+type logrusCore struct {
+	// level is the logrus level of the logger, accessed atomically.
+	// It is kept apart from that of the logrus logger, which is
+	// bypassed as entries are formatted and written directly.
+	level uint32
+
+	// mu guards the fields below, which are replaced on reconfiguration,
+	// and serializes the writes to the output
+	mu     sync.Mutex
+	log    *logrus.Logger
+	format string
+	utc    bool
+	output *output
+	syslog io.Closer // nil unless sending to syslog
+
+	*processor
 }
 
 // Name returns the name of the logg
@@ -50,161 +85,502 @@ func (l *LogrusLogger) Name() string {
 // Path returns the full path to the logger output, or empty string if
 // logging is not going to a file
 func (l *LogrusLogger) Path() string {
-	return l.path
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.output == nil {
+		return ""
+	}
+	return l.output.path
 }
 
-// Configure permits configuration of the logger via a Config struct
+// Configure permits configuration of the logger via a Config struct.
+// It may be called while other goroutines are logging.
 func (l *LogrusLogger) Configure(cfg *Config) error {
-	l.log.Out = os.Stdout
-	l.log.Level = l.toLogLevel(cfg.LogLevel)
-	l.log.Formatter = l.toOutputFormat(cfg.OutFormat)
+	level, err := l.toLogLevel(cfg.LogLevel)
+	if err != nil {
+		return err
+	}
 
-	l.path = strings.TrimSpace(cfg.Outfile)
-	if l.path != "" {
-		if err := l.logfileCheck(); err != nil {
-			return err
-		}
+	formatter, err := l.toOutputFormat(cfg)
+	if err != nil {
+		return err
+	}
+
+	stages, err := builtinStages(cfg)
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(cfg)
+	if err != nil {
+		return err
+	}
 
-		file, err := os.OpenFile(cfg.Outfile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0664)
+	hooks := make(logrus.LevelHooks)
+	var syslog io.Closer
+	if cfg.Syslog != nil {
+		var hook logrus.Hook
+		hook, syslog, err = newSyslogHook(cfg.Syslog)
 		if err != nil {
+			out.close()
 			return err
 		}
+		hooks.Add(hook)
+	}
 
-		l.log.Out = file
+	if cfg.LinePrefixTimestamp {
+		formatter = &timestampPrefixFormatter{formatter}
 	}
 
-	return nil
+	l.processor.configure(cfg, stages, l, out.budget)
+	atomic.StoreUint32(&l.level, uint32(level))
+
+	l.mu.Lock()
+	oldOut, oldSyslog := l.output, l.syslog
+	l.log.Formatter = formatter
+	l.log.Hooks = hooks
+	l.format = cfg.OutFormat
+	l.utc = cfg.UTC
+	l.output = out
+	l.syslog = syslog
+	l.log.Out = out.out
+	l.mu.Unlock()
+
+	if oldSyslog != nil {
+		oldSyslog.Close()
+	}
+	return oldOut.close()
 }
 
-// logfileCheck verifies, if logging to a file is requested, that the
-// file parent directory exists
-func (l *LogrusLogger) logfileCheck() error {
-	logfile := fs.NewFile(l.path)
-	logfileDir := logfile.Dir()
-	exists, err := logfileDir.Exists()
-	if err != nil {
-		return fmt.Errorf(
-			"unable to check if logfile parent directory exists: %v",
-			err,
-		)
+// Flush writes out any buffered entries and syncs the log file, if any,
+// leaving the logger open for further use
+func (l *LogrusLogger) Flush() error {
+	l.mu.Lock()
+	out := l.output
+	l.mu.Unlock()
+	return out.flush()
+}
+
+// Close writes out any buffered entries and closes the log file and syslog
+// connection, if any.
+// The logger, and any derived from it, should not be used afterwards.
+func (l *LogrusLogger) Close() error {
+	l.mu.Lock()
+	out, syslog := l.output, l.syslog
+	l.mu.Unlock()
+
+	if syslog != nil {
+		syslog.Close()
 	}
+	return out.close()
+}
 
-	if !exists {
-		return fmt.Errorf(
-			"log file parent directory inexistant, please create => %s",
-			logfileDir.Path,
-		)
+// SetLevel changes the level of the logger in place, and so also
+// of any child loggers derived from it, calling the functions
+// registered by OnLevelChange if the level changed
+func (l *LogrusLogger) SetLevel(level string) error {
+	lvl, err := l.toLogLevel(level)
+	if err != nil {
+		return err
 	}
 
+	l.changeLevel(l.GetLevel, func() {
+		atomic.StoreUint32(&l.level, uint32(lvl))
+	})
 	return nil
 }
 
-// Debug defines the debug level for this logger
-func (l *LogrusLogger) Debug(msg string, fields ...Field) {
-	fields = append(fields, source()...)
-	l.log.WithFields(mapify(fields...)).Debug(msg)
+// GetLevel returns the canonical name of the current level of the logger
+func (l *LogrusLogger) GetLevel() string {
+	return logrusLevelName(logrus.Level(atomic.LoadUint32(&l.level)))
 }
 
-// DebugL defines the debug level for more than one log line
-func (l *LogrusLogger) DebugL(msgs []string, fields ...Field) {
-	fieldsMap := mapify(fields...)
-	for _, line := range msgs {
-		l.log.WithFields(fieldsMap).Debug(line)
-	}
+// levelEnabled reports if the logger level lets through
+// entries at the given canonical level
+func (l *LogrusLogger) levelEnabled(level string) bool {
+	return logrusLevels[level] <= logrus.Level(atomic.LoadUint32(&l.level))
 }
 
-// Info defines the info level for this logger
-func (l *LogrusLogger) Info(msg string, fields ...Field) {
-	fields = append(fields, source()...)
-	l.log.WithFields(mapify(fields...)).Info(msg)
+// exit closes the logger and exits the program, if so configured
+func (l *LogrusLogger) exit() {
+	if !l.exitsOnFatal() {
+		return
+	}
+
+	l.Close()
+	l.log.Exit(1)
 }
 
-// InfoL defines the info level for more than one log line
-func (l *LogrusLogger) InfoL(msgs []string, fields ...Field) {
-	fieldsMap := mapify(fields...)
-	for _, line := range msgs {
-		l.log.WithFields(fieldsMap).Info(line)
+// write fires the logrus hooks sending to syslog, if any, then formats
+// the entry and writes it to the output for its level. The entry is
+// written directly, rather than logged via logrus, so as to return any
+// error raised, which logrus only reports to stderr.
+func (l *LogrusLogger) write(level, msg string, fields []Field) error {
+	// WithFields copies the map, so it can be released straight away
+	data := mapify(fields...)
+	entry := l.log.WithFields(data)
+	releaseMap(data)
+
+	entry.Level = logrusLevels[level]
+	entry.Message = msg
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Time = timestamp(l.utc)
+
+	// The first error raised is returned, but the line is
+	// still written everywhere it can be
+	firstErr := l.log.Hooks.Fire(entry.Level, entry)
+
+	line, err := l.log.Formatter.Format(entry)
+	if err != nil {
+		if firstErr == nil {
+			firstErr = err
+		}
+		return firstErr
+	}
+
+	if _, err := l.output.writerFor(level).Write(line); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	l.output.flushFor(level)
+
+	if l.also != nil {
+		if _, err := l.also.Write(line); err != nil && firstErr == nil {
+			firstErr = err
+		}
 	}
+	return firstErr
 }
 
-// Error defines the error level for this logger
-func (l *LogrusLogger) Error(msg string, fields ...Field) {
-	fields = append(fields, source()...)
-	l.log.WithFields(mapify(fields...)).Error(msg)
+// derive returns a child logger sharing the core of this one
+func (l *LogrusLogger) derive(bound []Field, component, ctxLevel string) Logger {
+	child := newLogrusLogger(l.logrusCore, bound, component)
+	child.ctxLevel = ctxLevel
+	child.also = l.also
+	return child
 }
 
-// ErrorL defines the error level for more than one log line
-func (l *LogrusLogger) ErrorL(msgs []string, fields ...Field) {
-	fieldsMap := mapify(fields...)
-	for _, line := range msgs {
-		l.log.WithFields(fieldsMap).Error(line)
-	}
+// ToAlso returns a logger that, for the calls made on it, writes each
+// formatted line to the given writer as well as to the configured output
+func (l *LogrusLogger) ToAlso(w io.Writer) LogLeveler {
+	child := newLogrusLogger(l.logrusCore, l.bound, l.component)
+	child.ctxLevel = l.ctxLevel
+	child.also = w
+	return child
 }
 
-// Fatal defines the fatal level for this logger
-func (l *LogrusLogger) Fatal(msg string, fields ...Field) {
-	fields = append(fields, source()...)
-	l.log.WithFields(mapify(fields...)).Fatal(msg)
+// Pipeline returns an ordered description of the processing stages
+// an entry passes through on its way to the output, for debugging
+func (l *LogrusLogger) Pipeline() []string {
+	stages := []string{"level:" + l.GetLevel()}
+	stages = append(stages, l.processor.stages()...)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stages = append(stages, "format:"+l.format)
+	if _, ok := l.log.Formatter.(*timestampPrefixFormatter); ok {
+		stages = append(stages, "prefix:timestamp")
+	}
+
+	if l.syslog != nil {
+		stages = append(stages, "syslog")
+	}
+	return append(stages, l.output.describe())
 }
 
-// FatalL defines the fatal level for more than one log line
-func (l *LogrusLogger) FatalL(msgs []string, fields ...Field) {
-	fieldsMap := mapify(fields...)
-	for _, line := range msgs {
-		l.log.WithFields(fieldsMap).Fatal(line)
+// logrusFieldMap returns the logrus field map renaming
+// the keys as set by the Config FieldKey fields
+func logrusFieldMap(cfg *Config) logrus.FieldMap {
+	fieldMap := logrus.FieldMap{}
+	if cfg.FieldKeyMsg != "" {
+		fieldMap[logrus.FieldKeyMsg] = cfg.FieldKeyMsg
+	}
+
+	if cfg.FieldKeyLevel != "" {
+		fieldMap[logrus.FieldKeyLevel] = cfg.FieldKeyLevel
 	}
+
+	if cfg.FieldKeyTime != "" {
+		fieldMap[logrus.FieldKeyTime] = cfg.FieldKeyTime
+	}
+	return fieldMap
 }
 
-func (l *LogrusLogger) toOutputFormat(name string) logrus.Formatter {
+func (l *LogrusLogger) toOutputFormat(cfg *Config) (logrus.Formatter, error) {
 	var formatter logrus.Formatter
 
-	switch name {
+	if cfg.ForceColors && cfg.DisableColors {
+		return nil, fmt.Errorf("ForceColors and DisableColors cannot both be set")
+	}
+
+	if cfg.PrettyJSON && cfg.OutFormat != "json" {
+		return nil, fmt.Errorf("PrettyJSON requires the json OutFormat, not %s", cfg.OutFormat)
+	}
+
+	switch cfg.OutFormat {
 	case "json":
-		formatter = &logrus.JSONFormatter{}
+		json := &logrus.JSONFormatter{
+			TimestampFormat:  cfg.TimeFormat,
+			DisableTimestamp: cfg.DisableTimestamp,
+			FieldMap:         logrusFieldMap(cfg),
+			PrettyPrint:      cfg.PrettyJSON,
+		}
+
+		formatter = json
+		if cfg.EpochTime && !cfg.DisableTimestamp {
+			formatter = newEpochJSONFormatter(json, cfg.EpochMillis)
+		}
 	case "text":
+		timeFormat := cfg.TimeFormat
+		if timeFormat == "" {
+			timeFormat = "2006-01-02 15:04:05"
+		}
+
 		formatter = &logrus.TextFormatter{
-			FullTimestamp:   true,
-			TimestampFormat: "2006-01-02 15:04:05",
+			FullTimestamp:    true,
+			TimestampFormat:  timeFormat,
+			FieldMap:         logrusFieldMap(cfg),
+			DisableTimestamp: cfg.DisableTimestamp,
+			ForceColors:      cfg.ForceColors,
+			DisableColors:    cfg.DisableColors,
 		}
+	case "logfmt":
+		formatter = &logfmtFormatter{
+			timeFormat:       cfg.TimeFormat,
+			disableTimestamp: cfg.DisableTimestamp,
+		}
+	case "ecs":
+		formatter = &ecsFormatter{}
+	case "apache":
+		formatter = &apacheFormatter{}
 	default:
-		panic("Unknown formatter " + name + ". Legal: json | text")
+		return nil, fmt.Errorf(
+			"unknown formatter %s. Legal: json | text | logfmt | ecs | apache",
+			cfg.OutFormat,
+		)
 	}
 
-	return formatter
+	return formatter, nil
 }
 
-func (l *LogrusLogger) toLogLevel(name string) logrus.Level {
-	name = strings.TrimSpace(name)
+// timestampPrefixFormatter wraps a logrus formatter, prepending
+// an RFC3339 timestamp to each formatted line
+type timestampPrefixFormatter struct {
+	logrus.Formatter
+}
 
-	switch name {
-	case "debug":
-		return logrus.DebugLevel
-	case "info":
-		return logrus.InfoLevel
-	case "error":
-		return logrus.ErrorLevel
-	default:
-		var msg = fmt.Sprintf(
-			"Unknown log level: %s. Legal values: debug, info, error",
-			name,
-		)
+// Format renders the entry with the wrapped formatter and prefixes the result
+func (f *timestampPrefixFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	line, err := f.Formatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
 
-		if len(name) == 0 {
-			msg = "Please provide a log level. Legal values: debug, info, error"
-		}
-		panic(msg)
+	prefix := entry.Time.Format(time.RFC3339) + " "
+	return append([]byte(prefix), line...), nil
+}
+
+// logrusLevels maps the canonical level names to the logrus levels
+var logrusLevels = map[string]logrus.Level{
+	levelTrace: logrus.TraceLevel,
+	levelDebug: logrus.DebugLevel,
+	levelInfo:  logrus.InfoLevel,
+	levelWarn:  logrus.WarnLevel,
+	levelError: logrus.ErrorLevel,
+	levelFatal: logrus.FatalLevel,
+}
+
+func (l *LogrusLogger) toLogLevel(name string) (logrus.Level, error) {
+	name, err := parseLevel(name)
+	if err != nil {
+		return 0, err
+	}
+	return logrusLevels[name], nil
+}
+
+// logrusLevelName returns the canonical name of the given logrus level
+func logrusLevelName(level logrus.Level) string {
+	switch level {
+	case logrus.TraceLevel:
+		return levelTrace
+	case logrus.DebugLevel:
+		return levelDebug
+	case logrus.InfoLevel:
+		return levelInfo
+	case logrus.WarnLevel:
+		return levelWarn
+	case logrus.ErrorLevel:
+		return levelError
+	case logrus.FatalLevel:
+		return levelFatal
+	default:
+		return level.String()
 	}
 }
 
 // ------------------------------------------------------------------
 
+// fieldMaps pools the maps built by mapify, to save allocating
+// and growing a new map on every log call
+var fieldMaps = sync.Pool{}
+
 // mapify converts the slice of Fields into a map keyed on Field.Name
-// which can be passed to logrus' WithFields method
+// which can be passed to logrus' WithFields method. The last of any
+// fields sharing a name wins, and fields without a name are skipped.
+// Callers which do not keep the map may hand it back with releaseMap
+// once done with it.
 func mapify(fields ...Field) map[string]interface{} {
-	data := map[string]interface{}{}
+	data, ok := fieldMaps.Get().(map[string]interface{})
+	if !ok {
+		data = make(map[string]interface{}, len(fields))
+	}
+
 	for _, f := range fields {
-		data[f.Name] = f.Val
+		if f.Name == "" {
+			continue
+		}
+		data[f.Name] = serializable(f.Val)
 	}
 
 	return data
 }
+
+// releaseMap clears a map built by mapify and returns it to the pool
+func releaseMap(data map[string]interface{}) {
+	for name := range data {
+		delete(data, name)
+	}
+	fieldMaps.Put(data)
+}
+
+// maxSerializableDepth bounds how deeply serializable looks into
+// nested values, guarding against cyclic ones
+const maxSerializableDepth = 32
+
+// serializable replaces values that cannot be sensibly formatted or
+// JSON-encoded (functions, channels, unsafe pointers) with a placeholder
+// naming their kind. Maps, slices and arrays holding any such value, at
+// whatever depth, are copied into their generic form with the
+// placeholders in place, and are otherwise returned unchanged.
+func serializable(val interface{}) interface{} {
+	if val == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(val)
+	switch v.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return unserializableValue(v)
+	case reflect.Map, reflect.Slice, reflect.Array:
+		if holdsUnserializable(v, 1) {
+			return sanitize(v, 1)
+		}
+	}
+	return val
+}
+
+// unserializableValue returns the placeholder for the given value
+func unserializableValue(v reflect.Value) string {
+	return "<" + v.Kind().String() + ">"
+}
+
+// holdsUnserializable reports if the value, which sits at the given
+// depth, is or contains a value that serializable replaces. Structs are
+// not looked into.
+func holdsUnserializable(v reflect.Value, depth int) bool {
+	if !v.IsValid() || depth > maxSerializableDepth {
+		return false
+	}
+
+	switch v.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return true
+
+	case reflect.Ptr, reflect.Interface:
+		return !v.IsNil() && holdsUnserializable(v.Elem(), depth+1)
+
+	case reflect.Map:
+		if !mayHoldUnserializable(v.Type().Elem(), depth) {
+			return false
+		}
+
+		iter := v.MapRange()
+		for iter.Next() {
+			if holdsUnserializable(iter.Value(), depth+1) {
+				return true
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		if !mayHoldUnserializable(v.Type().Elem(), depth) {
+			return false
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if holdsUnserializable(v.Index(i), depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mayHoldUnserializable reports if values of the given type, sitting
+// at the given depth, may be or contain a value that serializable
+// replaces, so that those which cannot need not be walked
+func mayHoldUnserializable(t reflect.Type, depth int) bool {
+	if depth > maxSerializableDepth {
+		return false
+	}
+
+	switch t.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Array:
+		return mayHoldUnserializable(t.Elem(), depth+1)
+	default:
+		return false
+	}
+}
+
+// sanitize returns the value, which sits at the given depth, with any
+// values that serializable replaces swapped for their placeholder, the
+// maps, slices and arrays holding them being converted to their generic
+// form on the way
+func sanitize(v reflect.Value, depth int) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if !holdsUnserializable(v, depth) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return sanitize(v.Elem(), depth+1)
+
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			m[key] = sanitize(iter.Value(), depth+1)
+		}
+		return m
+
+	case reflect.Slice, reflect.Array:
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			s[i] = sanitize(v.Index(i), depth+1)
+		}
+		return s
+
+	default:
+		return unserializableValue(v)
+	}
+}
@@ -0,0 +1,389 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSerializable(t *testing.T) {
+	fn := func() {}
+	ch := make(chan int)
+
+	tests := []struct {
+		name string
+		val  interface{}
+		want interface{}
+	}{
+		{"nil", nil, nil},
+		{"string", "s", "s"},
+		{"func", fn, "<func>"},
+		{"chan", ch, "<chan>"},
+		{"plain map", map[string]int{"a": 1}, map[string]int{"a": 1}},
+		{"plain slice", []string{"a"}, []string{"a"}},
+		{
+			"map with func",
+			map[string]interface{}{"f": fn, "n": 1},
+			map[string]interface{}{"f": "<func>", "n": 1},
+		},
+		{
+			"nested",
+			[]interface{}{"a", map[int]interface{}{1: []interface{}{ch}}},
+			[]interface{}{"a", map[string]interface{}{"1": []interface{}{"<chan>"}}},
+		},
+		{"slice of funcs", []func(){fn}, []interface{}{"<func>"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serializable(tt.val); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONWithUnserializableNestedValue(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := NewClient(name, &Config{OutFormat: "json", Writer: &buf})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = l.TryInfo("msg", F("m", map[string]interface{}{"f": func() {}, "n": 1}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var line map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+				t.Fatalf("invalid json %q: %v", buf.String(), err)
+			}
+
+			m, ok := line["m"].(map[string]interface{})
+			if !ok || m["f"] != "<func>" || m["n"] != 1.0 {
+				t.Errorf("got m=%v in %s", line["m"], strings.TrimSpace(buf.String()))
+			}
+		})
+	}
+}
+
+func TestLinePrefixTimestamp(t *testing.T) {
+	for _, format := range []string{"json", "text"} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := NewLogrusLogger(&Config{Writer: &buf, OutFormat: format, LinePrefixTimestamp: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := time.Now().Truncate(time.Second)
+			l.Info("hello")
+			after := time.Now()
+
+			line := buf.String()
+			i := strings.IndexByte(line, ' ')
+			if i < 0 {
+				t.Fatalf("no prefix in %q", line)
+			}
+
+			stamp, err := time.Parse(time.RFC3339, line[:i])
+			if err != nil {
+				t.Fatalf("the line does not start with an RFC3339 timestamp: %q", line)
+			}
+			if stamp.Before(before) || stamp.After(after) {
+				t.Errorf("got timestamp %v, want between %v and %v", stamp, before, after)
+			}
+
+			if rest := line[i+1:]; !strings.Contains(rest, "hello") {
+				t.Errorf("got %q after the prefix, want the formatted line", rest)
+			}
+		})
+	}
+
+	for _, name := range []string{"zap", "slog"} {
+		if _, err := NewClient(name, &Config{Writer: &bytes.Buffer{}, LinePrefixTimestamp: true}); err == nil {
+			t.Errorf("%s: expected an error for LinePrefixTimestamp", name)
+		}
+	}
+}
+
+func TestUnknownOutFormat(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		if _, err := NewClient(name, &Config{Writer: &bytes.Buffer{}, OutFormat: "xml"}); err == nil {
+			t.Errorf("%s: expected an error for the xml format", name)
+		}
+	}
+}
+
+func TestReconfigureClosesFile(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogrusLogger(&Config{Outfile: filepath.Join(dir, "first.log")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := l.output.file
+
+	if err := l.Configure(&Config{LogLevel: "info", OutFormat: "text", Outfile: filepath.Join(dir, "second.log")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := first.Write([]byte("x")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("got %v writing to the first file, want it closed", err)
+	}
+
+	second := l.output.file
+	if err := l.Configure(&Config{LogLevel: "info", OutFormat: "text", Writer: ioutil.Discard}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := second.Write([]byte("x")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("got %v writing to the second file, want it closed", err)
+	}
+}
+
+// lineTime returns the timestamp of the given json or text line,
+// or false if it has none
+func lineTime(t *testing.T, format, line string) (string, bool) {
+	t.Helper()
+
+	if format == "json" {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			t.Fatalf("invalid json %q: %v", line, err)
+		}
+		ts, ok := fields["time"].(string)
+		return ts, ok
+	}
+
+	// logrus and slog write time=..., zap a tab separated console line
+	if strings.HasPrefix(line, "time=") {
+		return strings.Trim(strings.Fields(line)[0][len("time="):], `"`), true
+	}
+	if i := strings.IndexByte(line, '\t'); i > 0 && !strings.HasPrefix(line, "level=") {
+		if first := line[:i]; first != "info" {
+			return first, true
+		}
+	}
+	return "", false
+}
+
+func TestTimeFormat(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		for _, format := range []string{"json", "text"} {
+			var buf bytes.Buffer
+			l, err := NewClient(name, &Config{Writer: &buf, OutFormat: format, TimeFormat: time.RFC3339Nano})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Info("msg")
+
+			ts, ok := lineTime(t, format, buf.String())
+			if !ok {
+				t.Errorf("%s %s: no timestamp in %q", name, format, buf.String())
+				continue
+			}
+			if _, err := time.Parse(time.RFC3339Nano, ts); err != nil {
+				t.Errorf("%s %s: got timestamp %q, want the RFC3339Nano layout", name, format, ts)
+			}
+		}
+	}
+}
+
+func TestDisableTimestamp(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		for _, format := range []string{"json", "text"} {
+			var buf bytes.Buffer
+			l, err := NewClient(name, &Config{Writer: &buf, OutFormat: format, DisableTimestamp: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Info("msg")
+
+			if ts, ok := lineTime(t, format, buf.String()); ok {
+				t.Errorf("%s %s: got timestamp %q in %q, want none", name, format, ts, buf.String())
+			}
+		}
+	}
+}
+
+func TestColors(t *testing.T) {
+	tests := []struct {
+		force, disable bool
+	}{
+		{false, false},
+		{true, false},
+		{false, true},
+	}
+
+	for _, tt := range tests {
+		l, err := NewLogrusLogger(&Config{Writer: &bytes.Buffer{}, ForceColors: tt.force, DisableColors: tt.disable})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f, ok := l.log.Formatter.(*logrus.TextFormatter)
+		if !ok {
+			t.Fatalf("got formatter %T, want a text formatter", l.log.Formatter)
+		}
+		if f.ForceColors != tt.force || f.DisableColors != tt.disable {
+			t.Errorf("got ForceColors %v and DisableColors %v, want %v and %v",
+				f.ForceColors, f.DisableColors, tt.force, tt.disable)
+		}
+	}
+
+	if _, err := NewLogrusLogger(&Config{Writer: &bytes.Buffer{}, ForceColors: true, DisableColors: true}); err == nil {
+		t.Error("expected an error for ForceColors with DisableColors")
+	}
+}
+
+func TestMapifyLastWins(t *testing.T) {
+	data := mapify(Str("k", "first"), Int("n", 1), Str("k", "last"), Field{})
+	defer releaseMap(data)
+
+	want := map[string]interface{}{"k": "last", "n": 1}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("got %v, want %v", data, want)
+	}
+}
+
+func TestFieldKeys(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		var buf bytes.Buffer
+		l, err := NewClient(name, &Config{
+			Writer:        &buf,
+			OutFormat:     "json",
+			FieldKeyMsg:   "message",
+			FieldKeyLevel: "severity",
+			FieldKeyTime:  "timestamp",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Error("renamed")
+
+		var line map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+			t.Fatalf("%s: invalid json %q: %v", name, buf.String(), err)
+		}
+		if line["message"] != "renamed" || line["severity"] != "error" || line["timestamp"] == nil {
+			t.Errorf("%s: got %v, want the renamed keys", name, line)
+		}
+		for _, key := range []string{"msg", "level", "time"} {
+			if _, ok := line[key]; ok {
+				t.Errorf("%s: got the default %s key as well", name, key)
+			}
+		}
+	}
+}
+
+func TestPrettyJSON(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := NewLogrusLogger(&Config{Writer: &buf, OutFormat: "json", PrettyJSON: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("pretty", Str("k", "v"))
+
+	out := buf.String()
+	if strings.Count(out, "\n") < 3 || !strings.Contains(out, "\n  \"k\": \"v\"") {
+		t.Errorf("got %q, want indented json", out)
+	}
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil || line["msg"] != "pretty" {
+		t.Errorf("got %v decoding %q, want the entry", err, out)
+	}
+
+	if _, err := NewLogrusLogger(&Config{Writer: &buf, OutFormat: "text", PrettyJSON: true}); err == nil {
+		t.Error("expected an error for PrettyJSON with the text format")
+	}
+	for _, name := range []string{"zap", "slog"} {
+		if _, err := NewClient(name, &Config{Writer: &buf, OutFormat: "json", PrettyJSON: true}); err == nil {
+			t.Errorf("%s: expected an error for PrettyJSON", name)
+		}
+	}
+}
+
+func TestEpochTime(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		for _, millis := range []bool{false, true} {
+			var buf bytes.Buffer
+			l, err := NewClient(name, &Config{Writer: &buf, OutFormat: "json", EpochTime: true, EpochMillis: millis})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := time.Now()
+			l.Info("epoch")
+			after := time.Now()
+
+			dec := json.NewDecoder(&buf)
+			dec.UseNumber()
+			var line map[string]interface{}
+			if err := dec.Decode(&line); err != nil {
+				t.Fatalf("%s: invalid json: %v", name, err)
+			}
+
+			num, ok := line["time"].(json.Number)
+			if !ok {
+				t.Errorf("%s: got time %#v, want a number", name, line["time"])
+				continue
+			}
+			stamp, err := num.Float64()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lo, hi := float64(before.Unix()), float64(after.Unix()+1)
+			if millis {
+				lo, hi = float64(before.UnixNano()/1e6), float64(after.UnixNano()/1e6+1)
+			}
+			if stamp < lo || stamp > hi {
+				t.Errorf("%s with EpochMillis %v: got time %v, want between %v and %v", name, millis, stamp, lo, hi)
+			}
+		}
+	}
+}
+
+// countingFormatter counts the entries formatted by the wrapped formatter
+type countingFormatter struct {
+	logrus.Formatter
+	n int
+}
+
+func (f *countingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	f.n++
+	return f.Formatter.Format(entry)
+}
+
+func TestSplitStreamsFormatsOnce(t *testing.T) {
+	read := captureStreams(t)
+
+	l, err := NewLogrusLogger(&Config{SplitStreams: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	formatter := &countingFormatter{Formatter: l.log.Formatter}
+	l.log.Formatter = formatter
+
+	l.Info("low")
+	l.Error("high")
+	l.Close()
+
+	if formatter.n != 2 {
+		t.Errorf("got %d entries formatted, want each formatted once", formatter.n)
+	}
+	if stdout, stderr := read(); !strings.Contains(stdout, "low") || !strings.Contains(stderr, "high") {
+		t.Errorf("got stdout %q and stderr %q, want the entries split", stdout, stderr)
+	}
+}
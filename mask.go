@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maskChars replaces the masked portion of a field value
+const maskChars = "***"
+
+// MaskSpec defines how much of a field value to leave visible when masking.
+// The first KeepPrefix and last KeepSuffix characters are kept, and
+// everything in between is replaced with ***.
+type MaskSpec struct {
+	KeepPrefix int `json:"keep_prefix" yaml:"keep_prefix"`
+	KeepSuffix int `json:"keep_suffix" yaml:"keep_suffix"`
+}
+
+// mask returns the masked form of the given value. Values too short
+// to keep the requested prefix and suffix are masked entirely.
+func (m MaskSpec) mask(val interface{}) string {
+	runes := []rune(fmt.Sprint(val))
+
+	prefix, suffix := m.KeepPrefix, m.KeepSuffix
+	if prefix < 0 {
+		prefix = 0
+	}
+	if suffix < 0 {
+		suffix = 0
+	}
+
+	if prefix+suffix >= len(runes) {
+		return maskChars
+	}
+
+	var b strings.Builder
+	b.WriteString(string(runes[:prefix]))
+	b.WriteString(maskChars)
+	b.WriteString(string(runes[len(runes)-suffix:]))
+	return b.String()
+}
+
+// maskFields returns an Enricher that masks the values of
+// any fields whose name appears in the given map
+func maskFields(specs map[string]MaskSpec) Enricher {
+	return func(fields []Field) []Field {
+		masked := make([]Field, len(fields))
+		for i, f := range fields {
+			if spec, ok := specs[f.Name]; ok {
+				f.Val = spec.mask(f.Val)
+			}
+			masked[i] = f
+		}
+		return masked
+	}
+}
+
+// redactValues returns an Enricher that replaces each part of any field
+// value matching one of the given regular expressions with ***, whatever
+// the field name. Values are matched in their fmt.Sprint form, and only
+// those that match are replaced.
+func redactValues(patterns []string) (Enricher, error) {
+	regexps := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %v", pattern, err)
+		}
+		regexps[i] = re
+	}
+
+	return func(fields []Field) []Field {
+		redacted := make([]Field, len(fields))
+		for i, f := range fields {
+			if f.Val != nil {
+				val := fmt.Sprint(f.Val)
+				matched := false
+				for _, re := range regexps {
+					if re.MatchString(val) {
+						val = re.ReplaceAllLiteralString(val, maskChars)
+						matched = true
+					}
+				}
+
+				if matched {
+					f.Val = val
+				}
+			}
+			redacted[i] = f
+		}
+		return redacted
+	}, nil
+}
+
+// sortedKeys returns the names of the masked keys in sorted order
+func sortedKeys(specs map[string]MaskSpec) []string {
+	keys := make([]string, 0, len(specs))
+	for k := range specs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
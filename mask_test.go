@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestMaskSpec(t *testing.T) {
+	tests := []struct {
+		spec MaskSpec
+		val  interface{}
+		want string
+	}{
+		{MaskSpec{}, "secret", "***"},
+		{MaskSpec{KeepPrefix: 2}, "secret", "se***"},
+		{MaskSpec{KeepSuffix: 2}, "secret", "***et"},
+		{MaskSpec{KeepPrefix: 4, KeepSuffix: 4}, "4111111111111111", "4111***1111"},
+		{MaskSpec{KeepPrefix: 3, KeepSuffix: 3}, "secret", "***"},
+		{MaskSpec{KeepPrefix: 5, KeepSuffix: 5}, "short", "***"},
+		{MaskSpec{KeepPrefix: 10}, "short", "***"},
+		{MaskSpec{KeepPrefix: -1, KeepSuffix: 1}, "secret", "***t"},
+		{MaskSpec{KeepPrefix: 1, KeepSuffix: 1}, "pässwörd", "p***d"},
+		{MaskSpec{KeepPrefix: 1}, 123456, "1***"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.spec.mask(tt.val); got != tt.want {
+			t.Errorf("%+v of %v: got %q, want %q", tt.spec, tt.val, got, tt.want)
+		}
+	}
+}
+
+func TestMaskKeysJSON(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := NewClient(name, &Config{
+				Writer:    &buf,
+				OutFormat: "json",
+				MaskKeys: map[string]MaskSpec{
+					"card":  {KeepPrefix: 4, KeepSuffix: 4},
+					"token": {},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			l.Info("paid", Str("card", "4111111111111111"), Str("token", "abc"), Str("user", "bob"))
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("%v: %s", err, buf.String())
+			}
+
+			want := map[string]string{"card": "4111***1111", "token": "***", "user": "bob"}
+			for k, v := range want {
+				if got[k] != v {
+					t.Errorf("got %s %v, want %s", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactPatterns(t *testing.T) {
+	l, err := NewMemoryLogger(&Config{RedactPatterns: []string{`\b\d{16}\b`, `Bearer \S+`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Info("msg", Str("card", "paid with 4111111111111111"), Str("auth", "Bearer abc.def"), Int("n", 42))
+	entry, _ := l.LastEntry()
+
+	want := map[string]interface{}{"card": "paid with ***", "auth": "***", "n": 42}
+	for name, val := range want {
+		if got, _ := entry.Field(name); got != val {
+			t.Errorf("got %s field %v, want %v", name, got, val)
+		}
+	}
+
+	if _, err := NewMemoryLogger(&Config{RedactPatterns: []string{"("}}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,253 @@
+package logging
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// defaultMemoryConfig returns the defaults of the memory logger,
+// which records entries at all levels
+func defaultMemoryConfig() *Config {
+	cfg := defaultConfig()
+	cfg.LogLevel = "trace"
+	return cfg
+}
+
+// NewMemoryLogger creates a new MemoryLogger, recording entries
+// at all levels unless the given Config sets a LogLevel
+func NewMemoryLogger(cfg *Config) (*MemoryLogger, error) {
+	cfg, err := checkedConfig(defaultMemoryConfig(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	l := newMemoryLogger(&memoryCore{processor: &processor{}}, nil, "")
+	if err := l.Configure(cfg); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// newMemoryLogger returns a logger on the given core,
+// binding the given fields and component
+func newMemoryLogger(core *memoryCore, bound []Field, component string) *MemoryLogger {
+	l := &MemoryLogger{memoryCore: core}
+	l.emitter = emitter{
+		proc:      core.processor,
+		backend:   l,
+		bound:     bound,
+		component: component,
+	}
+	return l
+}
+
+// ------------------------------------------------------------------
+
+// Entry is a log entry recorded by a MemoryLogger. Its fields are those
+// that would have been output, sorted by name, with the last of any
+// fields sharing a name winning.
+type Entry struct {
+	Level  string
+	Msg    string
+	Fields []Field
+}
+
+// Field returns the value of the named field of the entry,
+// and whether it was present
+func (e Entry) Field(name string) (interface{}, bool) {
+	for _, f := range e.Fields {
+		if f.Name == name {
+			return f.Val, true
+		}
+	}
+	return nil, false
+}
+
+// MemoryLogger defines a logger recording its entries in memory rather
+// than writing them anywhere, for tests to make assertions on. It honours
+// the LogLevel Config field, as well as those handled independently of the
+// backend, such as MaskKeys or ReportCaller. Fatal records the entry and
+// returns, whatever the ExitOnFatal setting. It is safe for concurrent use.
+type MemoryLogger struct {
+	*memoryCore
+	emitter
+
+	// also, if set, is written a logfmt line for each entry,
+	// as created by ToAlso
+	also io.Writer
+}
+
+// memoryCore holds the configuration and recorded entries of a
+// MemoryLogger, shared between a logger and the child loggers derived
+// from it
+type memoryCore struct {
+	mu      sync.Mutex
+	level   string
+	entries []Entry
+
+	*processor
+}
+
+// Name returns the name of the logger
+func (l *MemoryLogger) Name() string {
+	return "memory"
+}
+
+// Path returns the empty string, as this logger never logs to a file
+func (l *MemoryLogger) Path() string {
+	return ""
+}
+
+// Configure permits configuration of the logger via a Config struct
+func (l *MemoryLogger) Configure(cfg *Config) error {
+	level, err := parseLevel(cfg.LogLevel)
+	if err != nil {
+		return err
+	}
+
+	stages, err := builtinStages(cfg)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.level = level
+	l.mu.Unlock()
+
+	l.processor.configure(cfg, stages, l, newBufferBudget(cfg.MaxBufferMemoryMB))
+	return nil
+}
+
+// SetLevel changes the level of the logger in place, and so also
+// of any child loggers derived from it, calling the functions
+// registered by OnLevelChange if the level changed
+func (l *MemoryLogger) SetLevel(level string) error {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	l.changeLevel(l.GetLevel, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.level = lvl
+	})
+	return nil
+}
+
+// GetLevel returns the canonical name of the current level of the logger
+func (l *MemoryLogger) GetLevel() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.level
+}
+
+// Entries returns a copy of the entries recorded so far, oldest first
+func (l *MemoryLogger) Entries() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Entry(nil), l.entries...)
+}
+
+// LastEntry returns the most recently recorded entry,
+// or false if there is none
+func (l *MemoryLogger) LastEntry() (Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.entries) == 0 {
+		return Entry{}, false
+	}
+	return l.entries[len(l.entries)-1], true
+}
+
+// Reset discards the entries recorded so far
+func (l *MemoryLogger) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = nil
+}
+
+// exitsOnFatal reports false, as this logger never exits
+func (l *MemoryLogger) exitsOnFatal() bool {
+	return false
+}
+
+// exit does nothing, as this logger never exits:
+// Fatal records the entry and returns
+func (l *MemoryLogger) exit() {}
+
+// Flush does nothing for this logger, whose entries are recorded at once
+func (l *MemoryLogger) Flush() error {
+	return nil
+}
+
+// Close does nothing for this logger, which keeps its entries
+func (l *MemoryLogger) Close() error {
+	return nil
+}
+
+// levelEnabled reports if the logger level lets through
+// entries at the given canonical level
+func (l *MemoryLogger) levelEnabled(level string) bool {
+	return levelRanks[level] >= levelRanks[l.GetLevel()]
+}
+
+// write records the entry, and writes it to any ToAlso writer
+func (l *MemoryLogger) write(level, msg string, fields []Field) error {
+	data := mapify(fields...)
+	entry := Entry{Level: level, Msg: msg, Fields: fieldsOf(data)}
+	releaseMap(data)
+
+	l.mu.Lock()
+	l.entries = append(l.entries, entry)
+	l.mu.Unlock()
+
+	if l.also == nil {
+		return nil
+	}
+
+	_, err := l.also.Write(entry.logfmt())
+	return err
+}
+
+// logfmt renders the entry as a logfmt line
+func (e Entry) logfmt() []byte {
+	var b bytes.Buffer
+	writeLogfmtPair(&b, "level", e.Level)
+	writeLogfmtPair(&b, "msg", e.Msg)
+	for _, f := range e.Fields {
+		writeLogfmtPair(&b, f.Name, logfmtValue(f.Val))
+	}
+
+	b.WriteByte('\n')
+	return b.Bytes()
+}
+
+// derive returns a child logger sharing the configuration
+// and recorded entries of this one
+func (l *MemoryLogger) derive(bound []Field, component, ctxLevel string) Logger {
+	child := newMemoryLogger(l.memoryCore, bound, component)
+	child.ctxLevel = ctxLevel
+	child.also = l.also
+	return child
+}
+
+// ToAlso returns a logger that, for the calls made on it, also writes
+// a logfmt line for each entry to the given writer
+func (l *MemoryLogger) ToAlso(w io.Writer) LogLeveler {
+	child := newMemoryLogger(l.memoryCore, l.bound, l.component)
+	child.ctxLevel = l.ctxLevel
+	child.also = w
+	return child
+}
+
+// Pipeline returns an ordered description of the processing stages
+// an entry passes through on its way to the output, for debugging
+func (l *MemoryLogger) Pipeline() []string {
+	stages := []string{"level:" + l.GetLevel()}
+	stages = append(stages, l.processor.stages()...)
+	return append(stages, "output:memory")
+}
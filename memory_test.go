@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestMemoryLogger(t *testing.T) {
+	l, err := NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := l.LastEntry(); ok {
+		t.Error("LastEntry: got an entry before logging")
+	}
+
+	l.Trace("trace")
+	l.WithFields(Str("child", "yes")).Error("failed", Int("n", 1))
+	l.Fatal("fatal but alive")
+
+	entries := l.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	if e := entries[1]; e.Level != "error" || e.Msg != "failed" {
+		t.Errorf("got %s %q, want error failed", e.Level, e.Msg)
+	}
+	if got, _ := entries[1].Field("child"); got != "yes" {
+		t.Errorf("got child field %v, want yes", got)
+	}
+	if got, _ := entries[1].Field("n"); got != 1 {
+		t.Errorf("got n field %v, want 1", got)
+	}
+	if e, _ := l.LastEntry(); e.Level != "fatal" {
+		t.Errorf("got last entry at %s, want fatal", e.Level)
+	}
+
+	// Entries returns a copy
+	entries[0].Msg = "changed"
+	if e := l.Entries()[0]; e.Msg != "trace" {
+		t.Errorf("got %q after changing the copy, want trace", e.Msg)
+	}
+
+	l.Reset()
+	if n := len(l.Entries()); n != 0 {
+		t.Errorf("got %d entries after Reset, want none", n)
+	}
+
+	if err := l.SetLevel("warn"); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("suppressed")
+	if n := len(l.Entries()); n != 0 {
+		t.Errorf("got %d entries below the level, want none", n)
+	}
+}
+
+func TestMemoryLoggerConcurrent(t *testing.T) {
+	l, err := NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const goroutines, each = 8, 100
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			child := l.WithFields(Str("child", "yes"))
+			for j := 0; j < each; j++ {
+				child.Info("msg", Int("j", j))
+				l.LastEntry()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(l.Entries()); n != goroutines*each {
+		t.Errorf("got %d entries, want %d", n, goroutines*each)
+	}
+}
@@ -0,0 +1,533 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// NewMultiLogger creates a logger which forwards every call to each
+// of the given loggers, in order
+func NewMultiLogger(loggers ...Logger) *MultiLogger {
+	levelers := make(multiLeveler, len(loggers))
+	for i, l := range loggers {
+		levelers[i] = l
+	}
+
+	return &MultiLogger{
+		loggers:      loggers,
+		multiLeveler: levelers,
+	}
+}
+
+// MultiLogger fans out to several loggers, for example to write json to
+// a file while writing text to stdout. Note that hooks added to it are
+// added to each of its loggers, and so fire once per logger.
+type MultiLogger struct {
+	loggers []Logger
+	multiLeveler
+}
+
+// Name returns the name of the logger, listing those it wraps
+func (m *MultiLogger) Name() string {
+	names := make([]string, len(m.loggers))
+	for i, l := range m.loggers {
+		names[i] = l.Name()
+	}
+	return "multi[" + strings.Join(names, ",") + "]"
+}
+
+// Path returns the first non-empty output path of the wrapped loggers
+func (m *MultiLogger) Path() string {
+	for _, l := range m.loggers {
+		if path := l.Path(); path != "" {
+			return path
+		}
+	}
+	return ""
+}
+
+// Configure configures each of the wrapped loggers with the given
+// Config, returning the first error encountered
+func (m *MultiLogger) Configure(cfg *Config) error {
+	var firstErr error
+	for _, l := range m.loggers {
+		if err := l.Configure(cfg); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WithFields returns a MultiLogger of the child loggers
+// of each of the wrapped loggers
+func (m *MultiLogger) WithFields(fields ...Field) Logger {
+	children := make([]Logger, len(m.loggers))
+	for i, l := range m.loggers {
+		children[i] = l.WithFields(fields...)
+	}
+	return NewMultiLogger(children...)
+}
+
+// Named returns a MultiLogger wrapping the named
+// child loggers of each of the wrapped loggers
+func (m *MultiLogger) Named(name string) Logger {
+	children := make([]Logger, len(m.loggers))
+	for i, l := range m.loggers {
+		children[i] = l.Named(name)
+	}
+	return NewMultiLogger(children...)
+}
+
+// WithCorrelationID returns a MultiLogger of the child loggers
+// carrying the correlation ID, under the key configured for each
+func (m *MultiLogger) WithCorrelationID(id string) Logger {
+	children := make([]Logger, len(m.loggers))
+	for i, l := range m.loggers {
+		children[i] = l.WithCorrelationID(id)
+	}
+	return NewMultiLogger(children...)
+}
+
+// WithContext returns a MultiLogger of the child loggers carrying
+// the fields taken from the context by each of the wrapped loggers
+func (m *MultiLogger) WithContext(ctx context.Context) Logger {
+	children := make([]Logger, len(m.loggers))
+	for i, l := range m.loggers {
+		children[i] = l.WithContext(ctx)
+	}
+	return NewMultiLogger(children...)
+}
+
+// AddEnricher adds the enricher to each of the wrapped loggers
+func (m *MultiLogger) AddEnricher(fn Enricher) {
+	for _, l := range m.loggers {
+		l.AddEnricher(fn)
+	}
+}
+
+// AddContextEnricher adds the context enricher
+// to each of the wrapped loggers
+func (m *MultiLogger) AddContextEnricher(fn ContextEnricher) {
+	for _, l := range m.loggers {
+		l.AddContextEnricher(fn)
+	}
+}
+
+// AddFilter adds the filter to each of the wrapped loggers
+func (m *MultiLogger) AddFilter(fn Filter) {
+	for _, l := range m.loggers {
+		l.AddFilter(fn)
+	}
+}
+
+// AddHook adds the hook to each of the wrapped loggers
+func (m *MultiLogger) AddHook(h Hook) {
+	for _, l := range m.loggers {
+		l.AddHook(h)
+	}
+}
+
+// HasHook reports if any of the wrapped loggers has
+// a hook with the given name registered
+func (m *MultiLogger) HasHook(name string) bool {
+	for _, l := range m.loggers {
+		if l.HasHook(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetLevel changes the level of each of the wrapped loggers
+func (m *MultiLogger) SetLevel(level string) error {
+	if _, err := parseLevel(level); err != nil {
+		return err
+	}
+
+	for _, l := range m.loggers {
+		if err := l.SetLevel(level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLevel returns the level of the first wrapped logger
+func (m *MultiLogger) GetLevel() string {
+	if len(m.loggers) == 0 {
+		return "none"
+	}
+	return m.loggers[0].GetLevel()
+}
+
+// OnLevelChange registers the function with each of the wrapped
+// loggers, so that it is called for each of them whose level changes
+func (m *MultiLogger) OnLevelChange(fn func(old, new string)) {
+	for _, l := range m.loggers {
+		l.OnLevelChange(fn)
+	}
+}
+
+// IsEnabled reports if an entry at the given level
+// would currently be output by any of the wrapped loggers
+func (m *MultiLogger) IsEnabled(level string) bool {
+	for _, l := range m.loggers {
+		if l.IsEnabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetLevelDefaultFields sets the default fields for the level
+// on each of the wrapped loggers
+func (m *MultiLogger) SetLevelDefaultFields(level string, fields ...Field) error {
+	if _, err := parseLevel(level); err != nil {
+		return err
+	}
+
+	for _, l := range m.loggers {
+		if err := l.SetLevelDefaultFields(level, fields...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToAlso returns a logger that, for the calls made on it, writes each
+// formatted line of the first wrapped logger to the given writer as well.
+// Only the first is used, so that the writer receives each line once.
+func (m *MultiLogger) ToAlso(w io.Writer) LogLeveler {
+	levelers := make(multiLeveler, len(m.loggers))
+	for i, l := range m.loggers {
+		if i == 0 {
+			levelers[i] = l.ToAlso(w)
+			continue
+		}
+		levelers[i] = l
+	}
+	return levelers
+}
+
+// Writer returns a writer logging each line written to it as a message
+// at the given level, for libraries which log to an io.Writer. A partial
+// line is kept until the rest of it is written.
+func (m *MultiLogger) Writer(level string) io.Writer {
+	return newLevelWriter(m, level)
+}
+
+// Printf calls Printf on each of the wrapped loggers,
+// each logging at its own PrintLevel
+func (m *MultiLogger) Printf(format string, args ...interface{}) {
+	for _, l := range m.loggers {
+		l.Printf(format, args...)
+	}
+}
+
+// Println calls Println on each of the wrapped loggers,
+// each logging at its own PrintLevel
+func (m *MultiLogger) Println(args ...interface{}) {
+	for _, l := range m.loggers {
+		l.Println(args...)
+	}
+}
+
+// LogEvery calls LogEvery on each of the wrapped loggers,
+// each limiting the rate independently
+func (m *MultiLogger) LogEvery(d time.Duration, level, msg string, fields ...Field) {
+	for _, l := range m.loggers {
+		l.LogEvery(d, level, msg, fields...)
+	}
+}
+
+// LogStatus calls LogStatus on each of the wrapped loggers
+func (m *MultiLogger) LogStatus(status int, msg string, fields ...Field) {
+	for _, l := range m.loggers {
+		l.LogStatus(status, msg, fields...)
+	}
+}
+
+// Pipeline returns the pipelines of each of the wrapped loggers,
+// each stage prefixed by the name and position of its logger
+func (m *MultiLogger) Pipeline() []string {
+	var stages []string
+	for i, l := range m.loggers {
+		for _, stage := range l.Pipeline() {
+			stages = append(stages, fmt.Sprintf("%s#%d %s", l.Name(), i+1, stage))
+		}
+	}
+	return stages
+}
+
+// Flush flushes each of the wrapped loggers, returning the first error
+func (m *MultiLogger) Flush() error {
+	return m.try(Logger.Flush)
+}
+
+// Close closes each of the wrapped loggers, returning the first error
+func (m *MultiLogger) Close() error {
+	return m.try(Logger.Close)
+}
+
+// Quiet quietens each of the wrapped loggers
+func (m *MultiLogger) Quiet(d time.Duration) {
+	for _, l := range m.loggers {
+		l.Quiet(d)
+	}
+}
+
+// Unquiet cancels any quiet window of each of the wrapped loggers
+func (m *MultiLogger) Unquiet() {
+	for _, l := range m.loggers {
+		l.Unquiet()
+	}
+}
+
+// TryTrace logs at the trace level to each of the wrapped
+// loggers, returning the first error encountered
+func (m *MultiLogger) TryTrace(msg string, fields ...Field) error {
+	return m.try(func(l Logger) error { return l.TryTrace(msg, fields...) })
+}
+
+// TryDebug logs at the debug level to each of the wrapped
+// loggers, returning the first error encountered
+func (m *MultiLogger) TryDebug(msg string, fields ...Field) error {
+	return m.try(func(l Logger) error { return l.TryDebug(msg, fields...) })
+}
+
+// TryInfo logs at the info level to each of the wrapped
+// loggers, returning the first error encountered
+func (m *MultiLogger) TryInfo(msg string, fields ...Field) error {
+	return m.try(func(l Logger) error { return l.TryInfo(msg, fields...) })
+}
+
+// TryWarn logs at the warn level to each of the wrapped
+// loggers, returning the first error encountered
+func (m *MultiLogger) TryWarn(msg string, fields ...Field) error {
+	return m.try(func(l Logger) error { return l.TryWarn(msg, fields...) })
+}
+
+// TryError logs at the error level to each of the wrapped
+// loggers, returning the first error encountered
+func (m *MultiLogger) TryError(msg string, fields ...Field) error {
+	return m.try(func(l Logger) error { return l.TryError(msg, fields...) })
+}
+
+// TraceCtx logs at the trace level to each of the wrapped loggers
+func (m *MultiLogger) TraceCtx(ctx context.Context, msg string, fields ...Field) {
+	for _, l := range m.loggers {
+		l.TraceCtx(ctx, msg, fields...)
+	}
+}
+
+// DebugCtx logs at the debug level to each of the wrapped loggers
+func (m *MultiLogger) DebugCtx(ctx context.Context, msg string, fields ...Field) {
+	for _, l := range m.loggers {
+		l.DebugCtx(ctx, msg, fields...)
+	}
+}
+
+// InfoCtx logs at the info level to each of the wrapped loggers
+func (m *MultiLogger) InfoCtx(ctx context.Context, msg string, fields ...Field) {
+	for _, l := range m.loggers {
+		l.InfoCtx(ctx, msg, fields...)
+	}
+}
+
+// WarnCtx logs at the warn level to each of the wrapped loggers
+func (m *MultiLogger) WarnCtx(ctx context.Context, msg string, fields ...Field) {
+	for _, l := range m.loggers {
+		l.WarnCtx(ctx, msg, fields...)
+	}
+}
+
+// ErrorCtx logs at the error level to each of the wrapped loggers
+func (m *MultiLogger) ErrorCtx(ctx context.Context, msg string, fields ...Field) {
+	for _, l := range m.loggers {
+		l.ErrorCtx(ctx, msg, fields...)
+	}
+}
+
+// try calls fn for each of the wrapped loggers,
+// returning the first error encountered
+func (m *MultiLogger) try(fn func(Logger) error) error {
+	var firstErr error
+	for _, l := range m.loggers {
+		if err := fn(l); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// ------------------------------------------------------------------
+
+// fatalLogger is implemented by the loggers which can log at the
+// fatal level without exiting
+type fatalLogger interface {
+	logFatal(msg string, fields []Field)
+	logFatalL(msgs []string, fields []Field)
+	exitsOnFatal() bool
+}
+
+// multiLeveler forwards the level methods to each of its LogLevelers
+type multiLeveler []LogLeveler
+
+// Trace defines the trace level for this logger
+func (m multiLeveler) Trace(msg string, fields ...Field) {
+	for _, l := range m {
+		l.Trace(msg, fields...)
+	}
+}
+
+// TraceL defines the trace level for more than one log line
+func (m multiLeveler) TraceL(msgs []string, fields ...Field) {
+	for _, l := range m {
+		l.TraceL(msgs, fields...)
+	}
+}
+
+// Debug defines the debug level for this logger
+func (m multiLeveler) Debug(msg string, fields ...Field) {
+	for _, l := range m {
+		l.Debug(msg, fields...)
+	}
+}
+
+// DebugL defines the debug level for more than one log line
+func (m multiLeveler) DebugL(msgs []string, fields ...Field) {
+	for _, l := range m {
+		l.DebugL(msgs, fields...)
+	}
+}
+
+// Info defines the info level for this logger
+func (m multiLeveler) Info(msg string, fields ...Field) {
+	for _, l := range m {
+		l.Info(msg, fields...)
+	}
+}
+
+// InfoL defines the info level for more than one log line
+func (m multiLeveler) InfoL(msgs []string, fields ...Field) {
+	for _, l := range m {
+		l.InfoL(msgs, fields...)
+	}
+}
+
+// Warn defines the warn level for this logger
+func (m multiLeveler) Warn(msg string, fields ...Field) {
+	for _, l := range m {
+		l.Warn(msg, fields...)
+	}
+}
+
+// WarnL defines the warn level for more than one log line
+func (m multiLeveler) WarnL(msgs []string, fields ...Field) {
+	for _, l := range m {
+		l.WarnL(msgs, fields...)
+	}
+}
+
+// Error defines the error level for this logger
+func (m multiLeveler) Error(msg string, fields ...Field) {
+	for _, l := range m {
+		l.Error(msg, fields...)
+	}
+}
+
+// ErrorL defines the error level for more than one log line
+func (m multiLeveler) ErrorL(msgs []string, fields ...Field) {
+	for _, l := range m {
+		l.ErrorL(msgs, fields...)
+	}
+}
+
+// Fatal defines the fatal level for this logger. The line is written
+// to every logger before exiting, unless none of them exits on fatal.
+func (m multiLeveler) Fatal(msg string, fields ...Field) {
+	m.logFatal(msg, fields)
+	m.exit()
+}
+
+// FatalL defines the fatal level for more than one log line.
+// The lines are written to every logger before exiting.
+func (m multiLeveler) FatalL(msgs []string, fields ...Field) {
+	m.logFatalL(msgs, fields)
+	m.exit()
+}
+
+// exit closes the LogLevelers and exits the program, if so configured
+func (m multiLeveler) exit() {
+	if !m.exitsOnFatal() {
+		return
+	}
+
+	m.close()
+	os.Exit(1)
+}
+
+// exitsOnFatal reports if any of the LogLevelers exits on fatal.
+// Those which cannot log at the fatal level without exiting
+// have already done so by the time this is checked, so that only
+// if there are nothing but those is the exit left to be done here.
+// An empty multiLeveler never exits.
+func (m multiLeveler) exitsOnFatal() bool {
+	found := false
+	for _, l := range m {
+		if fl, ok := l.(fatalLogger); ok {
+			if fl.exitsOnFatal() {
+				return true
+			}
+			found = true
+		}
+	}
+	return !found && len(m) > 0
+}
+
+// close closes those LogLevelers that can be, so that any
+// buffered entries are written out before exiting
+func (m multiLeveler) close() {
+	for _, l := range m {
+		if c, ok := l.(io.Closer); ok {
+			c.Close()
+		}
+	}
+}
+
+// logFatal logs at the fatal level to each of the LogLevelers without
+// exiting. Those unable to do so are called last, as they may exit.
+func (m multiLeveler) logFatal(msg string, fields []Field) {
+	var others []LogLeveler
+	for _, l := range m {
+		if fl, ok := l.(fatalLogger); ok {
+			fl.logFatal(msg, fields)
+		} else {
+			others = append(others, l)
+		}
+	}
+
+	for _, l := range others {
+		l.Fatal(msg, fields...)
+	}
+}
+
+// logFatalL logs the lines as for logFatal
+func (m multiLeveler) logFatalL(msgs []string, fields []Field) {
+	var others []LogLeveler
+	for _, l := range m {
+		if fl, ok := l.(fatalLogger); ok {
+			fl.logFatalL(msgs, fields)
+		} else {
+			others = append(others, l)
+		}
+	}
+
+	for _, l := range others {
+		l.FatalL(msgs, fields...)
+	}
+}
@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestMultiLoggerFatalWithoutExiting(t *testing.T) {
+	noExit := false
+	mem, err := NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zap, err := NewZapLogger(&Config{Writer: ioutil.Discard, ExitOnFatal: &noExit})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		loggers []Logger
+	}{
+		{"empty", nil},
+		{"null", []Logger{&NullLogger{}, NullLogger{}}},
+		{"memory and null", []Logger{mem, &NullLogger{}}},
+		{"no exit", []Logger{zap, &NullLogger{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiLogger(tt.loggers...)
+			if m.exitsOnFatal() {
+				t.Fatal("exitsOnFatal: got true, want false")
+			}
+
+			// Would end the test binary if it exited
+			m.Fatal("fatal")
+			m.FatalL([]string{"fatal"})
+		})
+	}
+
+	if n := len(mem.Entries()); n != 2 {
+		t.Errorf("memory logger recorded %d entries, want 2", n)
+	}
+}
+
+func TestMultiLoggerExitsOnFatal(t *testing.T) {
+	l, err := NewLogrusLogger(&Config{Writer: ioutil.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m := NewMultiLogger(&NullLogger{}, l); !m.exitsOnFatal() {
+		t.Error("exitsOnFatal: got false with an exiting logger, want true")
+	}
+}
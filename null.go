@@ -1,5 +1,12 @@
 package logging
 
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
 //NewNullLogger creates a new NullLogger
 func NewNullLogger(cfg *Config) (*NullLogger, error) {
 	l := &NullLogger{}
@@ -23,6 +30,87 @@ func (NullLogger) Path() string {
 // Configure permits configuration of the logger via a Config struct
 func (NullLogger) Configure(*Config) error { return nil }
 
+// AddEnricher does nothing for this logger
+func (NullLogger) AddEnricher(Enricher) {}
+
+// AddContextEnricher does nothing for this logger
+func (NullLogger) AddContextEnricher(ContextEnricher) {}
+
+// AddFilter does nothing for this logger
+func (NullLogger) AddFilter(Filter) {}
+
+// AddHook does nothing for this logger
+func (NullLogger) AddHook(Hook) {}
+
+// HasHook returns false, as no hook is ever registered
+func (NullLogger) HasHook(string) bool { return false }
+
+// SetLevel does nothing for this logger
+func (NullLogger) SetLevel(string) error { return nil }
+
+// OnLevelChange does nothing for this logger, whose level never changes
+func (NullLogger) OnLevelChange(func(old, new string)) {}
+
+// GetLevel returns none, as this logger outputs nothing
+func (NullLogger) GetLevel() string { return "none" }
+
+// IsEnabled returns false, as this logger outputs nothing
+func (NullLogger) IsEnabled(string) bool { return false }
+
+// SetLevelDefaultFields does nothing for this logger
+func (NullLogger) SetLevelDefaultFields(string, ...Field) error { return nil }
+
+// WithFields returns this logger, as there is nothing to bind fields to
+func (l NullLogger) WithFields(...Field) Logger { return l }
+
+// Named returns this logger, as there is nothing to name
+func (l NullLogger) Named(string) Logger { return l }
+
+// WithCorrelationID returns this logger, as there is nothing to bind the ID to
+func (l NullLogger) WithCorrelationID(string) Logger { return l }
+
+// WithContext returns this logger, as there is nothing to bind fields to
+func (l NullLogger) WithContext(context.Context) Logger { return l }
+
+// ToAlso returns this logger, as nothing is ever written
+func (l NullLogger) ToAlso(io.Writer) LogLeveler { return l }
+
+// Writer returns a writer discarding everything written to it
+func (NullLogger) Writer(string) io.Writer { return ioutil.Discard }
+
+// Printf does nothing for this logger
+func (NullLogger) Printf(string, ...interface{}) {}
+
+// Println does nothing for this logger
+func (NullLogger) Println(...interface{}) {}
+
+// LogEvery does nothing for this logger
+func (NullLogger) LogEvery(time.Duration, string, string, ...Field) {}
+
+// LogStatus does nothing for this logger
+func (NullLogger) LogStatus(int, string, ...Field) {}
+
+// Pipeline returns the single stage of this logger, which discards everything
+func (NullLogger) Pipeline() []string { return []string{"output:none"} }
+
+// Flush does nothing for this logger
+func (NullLogger) Flush() error { return nil }
+
+// Close does nothing for this logger
+func (NullLogger) Close() error { return nil }
+
+// Quiet does nothing for this logger
+func (NullLogger) Quiet(time.Duration) {}
+
+// Unquiet does nothing for this logger
+func (NullLogger) Unquiet() {}
+
+// Trace defines the trace level for this logger
+func (NullLogger) Trace(string, ...Field) {}
+
+// TraceL defines the trace level for this logger
+func (NullLogger) TraceL([]string, ...Field) {}
+
 // Debug defines the debug level for this logger
 func (NullLogger) Debug(string, ...Field) {}
 
@@ -35,6 +123,12 @@ func (NullLogger) Info(string, ...Field) {}
 // InfoL defines the info level for this logger
 func (NullLogger) InfoL([]string, ...Field) {}
 
+// Warn defines the warn level for this logger
+func (NullLogger) Warn(string, ...Field) {}
+
+// WarnL defines the warn level for this logger
+func (NullLogger) WarnL([]string, ...Field) {}
+
 // Error defines the error level for this logger
 func (NullLogger) Error(string, ...Field) {}
 
@@ -46,3 +140,42 @@ func (NullLogger) Fatal(string, ...Field) {}
 
 // FatalL defines the fatal level for this logger
 func (NullLogger) FatalL([]string, ...Field) {}
+
+// TraceCtx defines the trace level for this logger
+func (NullLogger) TraceCtx(context.Context, string, ...Field) {}
+
+// DebugCtx defines the debug level for this logger
+func (NullLogger) DebugCtx(context.Context, string, ...Field) {}
+
+// InfoCtx defines the info level for this logger
+func (NullLogger) InfoCtx(context.Context, string, ...Field) {}
+
+// WarnCtx defines the warn level for this logger
+func (NullLogger) WarnCtx(context.Context, string, ...Field) {}
+
+// ErrorCtx defines the error level for this logger
+func (NullLogger) ErrorCtx(context.Context, string, ...Field) {}
+
+// logFatal and logFatalL do nothing for this logger,
+// which a MultiLogger can then treat as never exiting
+func (NullLogger) logFatal(string, []Field) {}
+
+func (NullLogger) logFatalL([]string, []Field) {}
+
+// exitsOnFatal reports false, as this logger never exits
+func (NullLogger) exitsOnFatal() bool { return false }
+
+// TryTrace defines the trace level for this logger, which never fails
+func (NullLogger) TryTrace(string, ...Field) error { return nil }
+
+// TryDebug defines the debug level for this logger, which never fails
+func (NullLogger) TryDebug(string, ...Field) error { return nil }
+
+// TryInfo defines the info level for this logger, which never fails
+func (NullLogger) TryInfo(string, ...Field) error { return nil }
+
+// TryWarn defines the warn level for this logger, which never fails
+func (NullLogger) TryWarn(string, ...Field) error { return nil }
+
+// TryError defines the error level for this logger, which never fails
+func (NullLogger) TryError(string, ...Field) error { return nil }
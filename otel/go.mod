@@ -0,0 +1,25 @@
+module github.com/brinick/logging/otel
+
+go 1.21
+
+require (
+	github.com/brinick/logging v0.1.0
+	go.opentelemetry.io/otel/trace v1.0.0
+)
+
+require (
+	github.com/brinick/fs v0.0.0-20200323111627-1a7de91c34e8 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
+	github.com/sirupsen/logrus v1.4.2 // indirect
+	go.opentelemetry.io/otel v1.0.0 // indirect
+	go.uber.org/atomic v1.7.0 // indirect
+	go.uber.org/multierr v1.6.0 // indirect
+	go.uber.org/zap v1.21.0 // indirect
+	golang.org/x/sys v0.0.0-20210510120138-977fb7262007 // indirect
+	gopkg.in/natefinch/lumberjack.v2 v2.2.1 // indirect
+)
+
+// The logging module is built from the parent directory of this one. The
+// version required, used by the modules depending on this one, must be a
+// published tag of the logging module: see RELEASING.md.
+replace github.com/brinick/logging => ../
@@ -0,0 +1,28 @@
+// Package otel adds the IDs of the OpenTelemetry span carried by the
+// context passed to the WithContext and Ctx methods of a logging.Logger
+// to the entries logged. It is a module of its own, so that the logging
+// package itself does not depend on OpenTelemetry.
+package otel
+
+import (
+	"context"
+
+	"github.com/brinick/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Enricher is a logging.ContextEnricher adding the trace_id and span_id
+// fields of the span in the context, if it has a valid one. Register it
+// with the AddContextEnricher method of the logger.
+func Enricher(ctx context.Context, fields []logging.Field) []logging.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return fields
+	}
+
+	return append(
+		fields,
+		logging.Str("trace_id", sc.TraceID().String()),
+		logging.Str("span_id", sc.SpanID().String()),
+	)
+}
@@ -0,0 +1,73 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brinick/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newLogger(t *testing.T) *logging.MemoryLogger {
+	t.Helper()
+
+	l, err := logging.NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.AddContextEnricher(Enricher)
+	return l
+}
+
+func spanContext() context.Context {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	return trace.ContextWithSpanContext(context.Background(), sc)
+}
+
+func TestEnricher(t *testing.T) {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+
+	l := newLogger(t)
+	ctx := spanContext()
+
+	check := func(how string) {
+		t.Helper()
+
+		entry, ok := l.LastEntry()
+		if !ok {
+			t.Fatalf("%s: nothing logged", how)
+		}
+		if got, _ := entry.Field("trace_id"); got != traceID {
+			t.Errorf("%s: got trace_id %v, want %s", how, got, traceID)
+		}
+		if got, _ := entry.Field("span_id"); got != spanID {
+			t.Errorf("%s: got span_id %v, want %s", how, got, spanID)
+		}
+	}
+
+	l.InfoCtx(ctx, "ctx method")
+	check("InfoCtx")
+
+	l.WithContext(ctx).Warn("child logger")
+	check("WithContext")
+}
+
+func TestEnricherWithoutSpan(t *testing.T) {
+	l := newLogger(t)
+	l.InfoCtx(context.Background(), "no span")
+
+	entry, _ := l.LastEntry()
+	if _, ok := entry.Field("trace_id"); ok {
+		t.Error("trace_id added without a span in the context")
+	}
+	if _, ok := entry.Field("span_id"); ok {
+		t.Error("span_id added without a span in the context")
+	}
+}
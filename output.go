@@ -0,0 +1,276 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/brinick/fs"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// output is the destination of a logger, as set up from a Config
+type output struct {
+	path    string
+	stream  string // stdout or stderr, if not logging to a file
+	out     io.Writer
+	errOut  io.Writer      // nil unless splitting streams, for error and above
+	file    io.WriteCloser // nil unless logging to a file
+	async   []*asyncWriter // nil unless logging asynchronously
+	discard bool           // set if entries only go to syslog
+	writer  bool           // set if writing to the Config.Writer
+
+	// budget caps the memory held by the async writers, and
+	// the dedup window of the logger, if so configured
+	budget *bufferBudget
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// openOutput opens the output described by the given Config: the Writer,
+// stdout, or the Outfile (optionally rotating, and also to stdout if
+// requested), written to asynchronously if requested. Nothing is opened if entries
+// are to be sent only to syslog.
+func openOutput(cfg *Config) (*output, error) {
+	o, err := openSyncOutput(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	o.budget = newBufferBudget(cfg.MaxBufferMemoryMB)
+	if cfg.Async && !o.discard {
+		o.out = o.asyncWriter(o.out, cfg.BufferSize)
+		if o.errOut != nil {
+			o.errOut = o.asyncWriter(o.errOut, cfg.BufferSize)
+		}
+	}
+	return o, nil
+}
+
+// asyncWriter wraps the given writer to be written to asynchronously
+func (o *output) asyncWriter(w io.Writer, size int) io.Writer {
+	a := newAsyncWriter(w, size, o.budget)
+	o.async = append(o.async, a)
+	return a
+}
+
+// openSyncOutput opens the output described by the given Config,
+// as for openOutput, ignoring any request to write asynchronously
+func openSyncOutput(cfg *Config) (*output, error) {
+	if cfg.Syslog != nil && cfg.Syslog.Only {
+		return &output{out: ioutil.Discard, discard: true}, nil
+	}
+
+	if cfg.Writer != nil {
+		return &output{out: cfg.Writer, writer: true}, nil
+	}
+
+	stream, err := openStream(cfg.Stream)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &output{
+		path:   strings.TrimSpace(cfg.Outfile),
+		out:    stream,
+		stream: strings.TrimSpace(cfg.Stream),
+	}
+
+	if cfg.SplitStreams {
+		o.out, o.errOut = os.Stdout, os.Stderr
+	}
+
+	if o.path == "" {
+		return o, nil
+	}
+
+	if cfg.CreateDirs {
+		if err := os.MkdirAll(filepath.Dir(o.path), cfg.dirMode()); err != nil {
+			return nil, fmt.Errorf(
+				"unable to create logfile parent directory: %v",
+				err,
+			)
+		}
+	} else if err := logfileCheck(o.path); err != nil {
+		return nil, err
+	}
+
+	file, err := openLogfile(o.path, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	o.file = file
+	switch {
+	case cfg.SplitStreams:
+		o.out = io.MultiWriter(file, os.Stdout)
+		o.errOut = io.MultiWriter(file, os.Stderr)
+	case cfg.AlsoStdout:
+		o.out = io.MultiWriter(file, stream)
+	default:
+		o.out = file
+	}
+
+	return o, nil
+}
+
+// close writes out any entries still buffered for asynchronous output,
+// then closes the output log file, if any. It may be called more than
+// once, returning the result of the first call.
+func (o *output) close() error {
+	if o == nil {
+		return nil
+	}
+
+	o.closeOnce.Do(func() {
+		for _, a := range o.async {
+			a.Close()
+		}
+
+		if o.file != nil {
+			o.closeErr = o.file.Close()
+		}
+	})
+	return o.closeErr
+}
+
+// flush writes out any entries buffered for asynchronous output,
+// then commits the output log file, if any, to stable storage
+func (o *output) flush() error {
+	if o == nil {
+		return nil
+	}
+
+	for _, a := range o.async {
+		a.Flush()
+	}
+
+	if s, ok := o.file.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// flushFor writes out any entries buffered for asynchronous output if
+// the entry just written is at error level or above, so that it is not
+// lost should the program crash straight after. Entries at lower levels
+// are left to be written in the background.
+func (o *output) flushFor(level string) {
+	if o == nil || levelRanks[level] < levelRanks[levelError] {
+		return
+	}
+
+	for _, a := range o.async {
+		a.Flush()
+	}
+}
+
+// writerFor returns the writer for an entry at the given level:
+// the errOut if splitting streams and the entry is at error level
+// or above, else the out
+func (o *output) writerFor(level string) io.Writer {
+	if o.errOut != nil && levelRanks[level] >= levelRanks[levelError] {
+		return o.errOut
+	}
+	return o.out
+}
+
+// describe returns the output stage description for Pipeline
+func (o *output) describe() string {
+	if o != nil && o.discard {
+		return "output:none"
+	}
+	if o != nil && o.writer {
+		return "output:writer"
+	}
+	desc := "output:file"
+	switch {
+	case o != nil && o.errOut != nil && o.path == "":
+		desc = "output:stdout/stderr"
+	case o != nil && o.errOut != nil:
+		desc = "output:file,stdout/stderr"
+	case o == nil || o.path == "":
+		desc = "output:stdout"
+		if o != nil && o.stream != "" {
+			desc = "output:" + o.stream
+		}
+	}
+
+	if o != nil && o.async != nil {
+		desc += ":async"
+	}
+	return desc
+}
+
+// openStream returns the standard stream with the given name,
+// defaulting to stdout
+func openStream(name string) (io.Writer, error) {
+	switch strings.TrimSpace(name) {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	default:
+		return nil, fmt.Errorf("unknown stream %s. Legal: stdout | stderr", name)
+	}
+}
+
+// openLogfile opens the log file for appending, or truncated if so
+// configured, wrapping it in a rotating writer if any of the rotation
+// settings are given
+func openLogfile(path string, cfg *Config) (io.WriteCloser, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if cfg.Truncate {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(path, flags, cfg.fileMode())
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.rotates() {
+		return file, nil
+	}
+
+	// The file now exists with the desired permissions,
+	// which the rotating writer preserves on each rotation
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	return &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    cfg.MaxSizeMB,
+		MaxBackups: cfg.MaxBackups,
+		MaxAge:     cfg.MaxAgeDays,
+	}, nil
+}
+
+// logfileCheck verifies, if logging to a file is requested, that the
+// file parent directory exists
+func logfileCheck(path string) error {
+	logfile := fs.NewFile(path)
+	logfileDir := logfile.Dir()
+	exists, err := logfileDir.Exists()
+	if err != nil {
+		return fmt.Errorf(
+			"unable to check if logfile parent directory exists: %v",
+			err,
+		)
+	}
+
+	if !exists {
+		return fmt.Errorf(
+			"log file parent directory inexistant, please create => %s",
+			logfileDir.Path,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureStreams redirects stdout and stderr to temporary files for the
+// duration of the test, returning a function reading what was written
+// to each so far
+func captureStreams(t *testing.T) func() (stdout, stderr string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := make([]*os.File, 2)
+	for i, name := range []string{"stdout", "stderr"} {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[i] = f
+	}
+
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = files[0], files[1]
+	t.Cleanup(func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		files[0].Close()
+		files[1].Close()
+	})
+
+	return func() (string, string) {
+		out, err := ioutil.ReadFile(files[0].Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		errOut, err := ioutil.ReadFile(files[1].Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(out), string(errOut)
+	}
+}
+
+func TestStream(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		for _, stream := range []string{"", "stdout", "stderr"} {
+			read := captureStreams(t)
+
+			l, err := NewClient(name, &Config{Stream: stream})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Info("hello")
+
+			stdout, stderr := read()
+			got, other := stdout, stderr
+			if stream == "stderr" {
+				got, other = stderr, stdout
+			}
+			if !strings.Contains(got, "hello") || other != "" {
+				t.Errorf("%s with stream %q: got stdout %q and stderr %q", name, stream, stdout, stderr)
+			}
+		}
+
+		if _, err := NewClient(name, &Config{Stream: "stdin"}); err == nil {
+			t.Errorf("%s: expected an error for an unknown stream", name)
+		}
+	}
+}
+
+func TestSplitStreams(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		for _, toFile := range []bool{false, true} {
+			read := captureStreams(t)
+
+			cfg := &Config{LogLevel: "debug", SplitStreams: true}
+			if toFile {
+				cfg.Outfile = filepath.Join(t.TempDir(), "split.log")
+			}
+
+			l, err := NewClient(name, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Debug("low-debug")
+			l.Info("low-info")
+			l.Warn("low-warn")
+			l.Error("high-error")
+			l.Close()
+
+			stdout, stderr := read()
+			if strings.Count(stdout, "low-") != 3 || strings.Contains(stdout, "high-") {
+				t.Errorf("%s: got stdout %q, want the entries below error", name, stdout)
+			}
+			if strings.Count(stderr, "high-") != 1 || strings.Contains(stderr, "low-") {
+				t.Errorf("%s: got stderr %q, want the error entry", name, stderr)
+			}
+
+			if toFile {
+				data, err := ioutil.ReadFile(cfg.Outfile)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := string(data); strings.Count(got, "low-") != 3 || strings.Count(got, "high-") != 1 {
+					t.Errorf("%s: got file %q, want all the entries", name, got)
+				}
+			}
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		read := captureStreams(t)
+		outfile := filepath.Join(t.TempDir(), "unused.log")
+
+		var buf bytes.Buffer
+		l, err := NewClient(name, &Config{Writer: &buf, Outfile: outfile, OutFormat: "json"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Info("hello", Str("k", "v"))
+		l.Close()
+
+		var line map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+			t.Fatalf("%s: invalid json %q: %v", name, buf.String(), err)
+		}
+		if line["msg"] != "hello" || line["k"] != "v" {
+			t.Errorf("%s: got %v written, want the entry", name, line)
+		}
+
+		if _, err := os.Stat(outfile); !os.IsNotExist(err) {
+			t.Errorf("%s: got %v for the Outfile, want it not created", name, err)
+		}
+		if stdout, _ := read(); stdout != "" {
+			t.Errorf("%s: got stdout %q, want nothing", name, stdout)
+		}
+	}
+}
+
+func TestCreateDirs(t *testing.T) {
+	outfile := filepath.Join(t.TempDir(), "a", "b", "c", "app.log")
+
+	if _, err := NewLogrusLogger(&Config{Outfile: outfile}); err == nil {
+		t.Error("expected an error for a missing directory without CreateDirs")
+	}
+
+	l, err := NewLogrusLogger(&Config{Outfile: outfile, CreateDirs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("hello")
+	l.Close()
+
+	data, err := ioutil.ReadFile(outfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "hello") {
+		t.Errorf("got %q in the file, want the entry", data)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	for _, truncate := range []bool{false, true} {
+		outfile := filepath.Join(t.TempDir(), "app.log")
+		if err := ioutil.WriteFile(outfile, []byte("previous run\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		l, err := NewLogrusLogger(&Config{Outfile: outfile, Truncate: truncate})
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Info("this run")
+		l.Close()
+
+		data, err := ioutil.ReadFile(outfile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := string(data)
+		if strings.Contains(got, "previous run") == truncate || !strings.Contains(got, "this run") {
+			t.Errorf("with Truncate %v: got %q", truncate, got)
+		}
+	}
+}
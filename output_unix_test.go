@@ -0,0 +1,49 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestFileMode(t *testing.T) {
+	umask := syscall.Umask(0)
+	defer syscall.Umask(umask)
+
+	tests := []struct {
+		fileMode, dirMode         os.FileMode
+		wantFileMode, wantDirMode os.FileMode
+	}{
+		{0, 0, 0664, 0755},
+		{0600, 0700, 0600, 0700},
+	}
+
+	for _, tt := range tests {
+		dir := filepath.Join(t.TempDir(), "logs")
+		outfile := filepath.Join(dir, "app.log")
+
+		l, err := NewLogrusLogger(&Config{
+			Outfile:    outfile,
+			CreateDirs: true,
+			FileMode:   tt.fileMode,
+			DirMode:    tt.dirMode,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Close()
+
+		for path, want := range map[string]os.FileMode{outfile: tt.wantFileMode, dir: tt.wantDirMode} {
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.Mode().Perm(); got != want {
+				t.Errorf("%s: got mode %v, want %v", filepath.Base(path), got, want)
+			}
+		}
+	}
+}
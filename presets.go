@@ -0,0 +1,50 @@
+package logging
+
+// ------------------------------------------------------------------
+// Preset configurations
+// ------------------------------------------------------------------
+
+func developmentConfig() *Config {
+	reportCaller := true
+	return &Config{
+		OutFormat:    "text",
+		LogLevel:     "debug",
+		ForceColors:  true,
+		ReportCaller: &reportCaller,
+	}
+}
+
+func productionConfig() *Config {
+	reportCaller := true
+	return &Config{
+		OutFormat:     "json",
+		LogLevel:      "info",
+		UTC:           true,
+		DisableColors: true,
+		ReportCaller:  &reportCaller,
+		PanicSafe:     true,
+	}
+}
+
+// NewDevelopment returns a logger suited to local development:
+// colored human-readable text output at debug level, with caller
+// information
+func NewDevelopment() (Logger, error) {
+	return newPreset(developmentConfig())
+}
+
+// NewProduction returns a logger suited to production:
+// uncolored json output at info level, with UTC timestamps and caller
+// information, recovering from any panic raised while logging
+func NewProduction() (Logger, error) {
+	return newPreset(productionConfig())
+}
+
+// newPreset creates a logrus logger from the given preset config
+func newPreset(cfg *Config) (Logger, error) {
+	l, err := NewLogrusLogger(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDevelopmentConfig(t *testing.T) {
+	cfg := developmentConfig()
+
+	if cfg.OutFormat != "text" || cfg.LogLevel != "debug" {
+		t.Errorf("got %s at %s, want text at debug", cfg.OutFormat, cfg.LogLevel)
+	}
+	if !cfg.ForceColors || cfg.DisableColors {
+		t.Error("want colors forced on")
+	}
+	if cfg.ReportCaller == nil || !*cfg.ReportCaller {
+		t.Error("want caller reporting")
+	}
+	if cfg.UTC {
+		t.Error("want local timestamps")
+	}
+	if err := defaultConfig().Update(cfg).Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProductionConfig(t *testing.T) {
+	cfg := productionConfig()
+
+	if cfg.OutFormat != "json" || cfg.LogLevel != "info" {
+		t.Errorf("got %s at %s, want json at info", cfg.OutFormat, cfg.LogLevel)
+	}
+	if cfg.ForceColors || !cfg.DisableColors {
+		t.Error("want colors disabled")
+	}
+	if cfg.ReportCaller == nil || !*cfg.ReportCaller {
+		t.Error("want caller reporting")
+	}
+	if !cfg.UTC {
+		t.Error("want UTC timestamps")
+	}
+	if !cfg.PanicSafe {
+		t.Error("want panic safety")
+	}
+	if err := defaultConfig().Update(cfg).Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPresets(t *testing.T) {
+	tests := []struct {
+		name   string
+		new    func() (Logger, error)
+		level  string
+		format string
+	}{
+		{"development", NewDevelopment, "debug", "format:text"},
+		{"production", NewProduction, "info", "format:json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := tt.new()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Close()
+
+			if got := l.GetLevel(); got != tt.level {
+				t.Errorf("got level %s, want %s", got, tt.level)
+			}
+
+			pipeline := strings.Join(l.Pipeline(), " ")
+			if !strings.Contains(pipeline, tt.format) || !strings.Contains(pipeline, "source") {
+				t.Errorf("got pipeline %s, want %s with source", pipeline, tt.format)
+			}
+		})
+	}
+}
+
+func TestUTCTimestamps(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+5", 5*60*60)
+	defer func() { time.Local = local }()
+
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		for _, utc := range []bool{false, true} {
+			var buf bytes.Buffer
+			l, err := NewClient(name, &Config{
+				OutFormat:  "json",
+				TimeFormat: time.RFC3339,
+				UTC:        utc,
+				Writer:     &buf,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Info("msg")
+
+			var line map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+				t.Fatalf("%s: invalid json %q: %v", name, buf.String(), err)
+			}
+
+			ts, _ := line["time"].(string)
+			if strings.HasSuffix(ts, "Z") != utc {
+				t.Errorf("%s with UTC %v: got time %q", name, utc, ts)
+			}
+		}
+	}
+}
@@ -0,0 +1,458 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stage is a named Enricher set up from the logger configuration
+type stage struct {
+	name string
+	fn   Enricher
+}
+
+// processor holds the backend independent state used to build the fields
+// of each log entry (per-level defaults, enrichers and those stages implied
+// by the configuration) and to decide whether it is emitted at all.
+// It is shared by a logger and the child loggers derived from it.
+type processor struct {
+	mu           sync.RWMutex
+	settings     settings
+	enrichers    []Enricher
+	ctxEnrichers []ContextEnricher
+	filters      []Filter
+	builtins     []stage
+	hooks        []registeredHook
+	sampler      *sampler
+	dedup        *deduper
+	limiter      rateLimiter
+	quietUntil   time.Time
+	globalFields []Field
+	levelFields  map[string][]Field
+
+	componentLevels map[string]string
+	levelChanges    []func(old, new string)
+
+	// levelMu serializes the level changes made by SetLevel
+	levelMu sync.Mutex
+}
+
+// settings are the options of a processor set from the Config. They are
+// copied out under the lock by options, so that an entry is processed
+// with a consistent set of options while the logger is reconfigured.
+type settings struct {
+	omitUnknownSource bool
+	reportCaller      bool
+	callerSkip        int
+	combinedSource    bool
+	panicSafe         bool
+	exitOnFatal       bool
+	printLevel        string
+	maxMsgLen         int
+	correlationKey    string
+}
+
+// defaultCorrelationKey is the name of the field added by
+// WithCorrelationID if Config.CorrelationKey is not set
+const defaultCorrelationKey = "correlation_id"
+
+// builtinStages returns the stages implied by the given Config,
+// or an error if any of their settings, or the PrintLevel used with
+// them by the processor, is invalid
+func builtinStages(cfg *Config) ([]stage, error) {
+	if cfg.PrintLevel != "" {
+		if _, err := parseLevel(cfg.PrintLevel); err != nil {
+			return nil, err
+		}
+	}
+
+	var builtins []stage
+	if cfg.ExpandErrors {
+		builtins = append(builtins, stage{"expand-errors", expandErrors})
+	}
+	if len(cfg.MaskKeys) > 0 {
+		builtins = append(builtins, stage{
+			"mask:" + strings.Join(sortedKeys(cfg.MaskKeys), ","),
+			maskFields(cfg.MaskKeys),
+		})
+	}
+	if len(cfg.RedactPatterns) > 0 {
+		fn, err := redactValues(cfg.RedactPatterns)
+		if err != nil {
+			return nil, err
+		}
+
+		builtins = append(builtins, stage{
+			fmt.Sprintf("redact:%d", len(cfg.RedactPatterns)),
+			fn,
+		})
+	}
+	if cfg.MaxFieldDepth > 0 {
+		builtins = append(builtins, stage{
+			fmt.Sprintf("max-depth:%d", cfg.MaxFieldDepth),
+			limitDepth(cfg.MaxFieldDepth),
+		})
+	}
+	if cfg.MaxFieldLen > 0 {
+		builtins = append(builtins, stage{
+			fmt.Sprintf("max-field-len:%d", cfg.MaxFieldLen),
+			limitLength(cfg.MaxFieldLen),
+		})
+	}
+	if keys := reservedKeys(cfg); len(keys) > 0 {
+		builtins = append(builtins, stage{describeClashes(keys), prefixClashes(keys)})
+	}
+	return builtins, nil
+}
+
+// configure sets up the processor of the logger l from the given
+// Config, with the stages built from it by builtinStages, and the
+// budget capping the memory held by the dedup window
+func (p *processor) configure(cfg *Config, builtins []stage, l LogLeveler, budget *bufferBudget) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.settings = settings{
+		omitUnknownSource: cfg.OmitUnknownSource,
+		reportCaller:      cfg.ReportCaller == nil || *cfg.ReportCaller,
+		callerSkip:        cfg.CallerSkip,
+		combinedSource:    cfg.CombinedSource,
+		panicSafe:         cfg.PanicSafe,
+		exitOnFatal:       cfg.ExitOnFatal == nil || *cfg.ExitOnFatal,
+		printLevel:        cfg.PrintLevel,
+		maxMsgLen:         cfg.MaxMsgLen,
+		correlationKey:    cfg.CorrelationKey,
+	}
+	if p.settings.correlationKey == "" {
+		p.settings.correlationKey = defaultCorrelationKey
+	}
+	p.globalFields = append([]Field(nil), cfg.GlobalFields...)
+	p.builtins = builtins
+
+	p.componentLevels = nil
+	for component, level := range cfg.ComponentLevels {
+		if lvl, err := parseLevel(level); err == nil {
+			if p.componentLevels == nil {
+				p.componentLevels = map[string]string{}
+			}
+			p.componentLevels[component] = lvl
+		}
+	}
+
+	p.sampler = nil
+	if cfg.Sampling != nil {
+		p.sampler = newSampler(cfg.Sampling)
+	}
+
+	if p.dedup != nil {
+		p.dedup.stop()
+	}
+
+	p.dedup = nil
+	if cfg.DedupWindow > 0 {
+		p.dedup = newDeduper(cfg.DedupWindow, l, budget)
+	}
+}
+
+// componentLevel returns the canonical level set for the
+// given component in the configuration, if any
+func (p *processor) componentLevel(component string) (string, bool) {
+	if component == "" {
+		return "", false
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	level, ok := p.componentLevels[component]
+	return level, ok
+}
+
+// admit reports if the entry with the given level and message
+// passes sampling and deduplication, if any
+func (p *processor) admit(level, msg string) bool {
+	p.mu.RLock()
+	s, d := p.sampler, p.dedup
+	p.mu.RUnlock()
+
+	if s != nil && !s.allow(level, msg) {
+		return false
+	}
+	return d == nil || d.allow(level, msg)
+}
+
+// options returns the current settings of the processor
+func (p *processor) options() settings {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.settings
+}
+
+// source returns the fields reporting the caller,
+// or nothing if caller reporting is disabled
+func (s settings) source() []Field {
+	switch {
+	case !s.reportCaller:
+		return nil
+	case s.combinedSource:
+		return combinedSource(s.omitUnknownSource, s.callerSkip)
+	default:
+		return source(s.omitUnknownSource, s.callerSkip)
+	}
+}
+
+// AddEnricher registers a function that will be run on the fields of
+// every subsequent log entry, in the order of registration
+func (p *processor) AddEnricher(fn Enricher) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.enrichers = append(p.enrichers, fn)
+}
+
+// AddFilter registers a filter that will be run on every subsequent log
+// entry, after the enrichers, in the order of registration
+func (p *processor) AddFilter(fn Filter) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.filters = append(p.filters, fn)
+}
+
+// filter passes the entry through each registered filter in turn,
+// returning the possibly rewritten message and fields, or false if
+// any of the filters drops the entry. The message is then truncated
+// if longer than the configured maximum.
+func (p *processor) filter(level, msg string, fields []Field) (string, []Field, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, fn := range p.filters {
+		var keep bool
+		if msg, fields, keep = fn(level, msg, fields); !keep {
+			return "", nil, false
+		}
+	}
+
+	msg, _ = truncateString(msg, p.settings.maxMsgLen)
+	return msg, fields, true
+}
+
+// enrich evaluates any Lazy fields and then passes the fields through
+// each registered enricher in turn, followed by the enrichers implied by
+// the configuration (masking, depth and length limiting)
+func (p *processor) enrich(fields []Field) []Field {
+	fields = resolveLazy(fields)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, fn := range p.enrichers {
+		fields = fn(fields)
+	}
+
+	for _, st := range p.builtins {
+		fields = st.fn(fields)
+	}
+	return fields
+}
+
+// SetLevelDefaultFields sets the default fields to attach to every entry
+// logged at the given level. Per-call fields of the same name take priority.
+func (p *processor) SetLevelDefaultFields(level string, fields ...Field) error {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.levelFields == nil {
+		p.levelFields = map[string][]Field{}
+	}
+	p.levelFields[lvl] = fields
+	return nil
+}
+
+// defaults prepends the global fields, followed by any default fields
+// for the given level, so that the per-call fields win when mapified
+func (p *processor) defaults(level string, fields []Field) []Field {
+	p.mu.RLock()
+	globals := p.globalFields
+	defaults := p.levelFields[level]
+	p.mu.RUnlock()
+
+	return prependFields(globals, prependFields(defaults, fields))
+}
+
+// OnLevelChange registers a function to be called with the old and new
+// level names whenever SetLevel changes the level of the logger
+func (p *processor) OnLevelChange(fn func(old, new string)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.levelChanges = append(p.levelChanges, fn)
+}
+
+// changeLevel sets the level of the logger with set, then calls the
+// functions registered by OnLevelChange if that changed the level
+// reported by get
+func (p *processor) changeLevel(get func() string, set func()) {
+	p.levelMu.Lock()
+	old := get()
+	set()
+	level := get()
+	p.levelMu.Unlock()
+
+	if level == old {
+		return
+	}
+
+	p.mu.RLock()
+	fns := p.levelChanges
+	p.mu.RUnlock()
+
+	for _, fn := range fns {
+		fn(old, level)
+	}
+}
+
+// Quiet suppresses all log lines below error level for the given
+// duration, after which normal logging resumes automatically
+func (p *processor) Quiet(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.quietUntil = time.Now().Add(d)
+}
+
+// Unquiet cancels any active quiet window
+func (p *processor) Unquiet() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.quietUntil = time.Time{}
+}
+
+// quieted reports if we are currently within a quiet window
+func (p *processor) quieted() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return time.Now().Before(p.quietUntil)
+}
+
+// exitsOnFatal reports if the Fatal methods exit the program
+func (p *processor) exitsOnFatal() bool {
+	return p.options().exitOnFatal
+}
+
+// recoverPanic is deferred in the emit path when running panic safe,
+// dropping the line being logged and reporting the panic to stderr
+func (p *processor) recoverPanic() {
+	if r := recover(); r != nil {
+		fmt.Fprintf(os.Stderr, "logging: recovered from panic, dropping log line: %v\n", r)
+	}
+}
+
+// stages describes the field processing stages, in order
+func (p *processor) stages() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var stages []string
+	if len(p.componentLevels) > 0 {
+		components := make([]string, 0, len(p.componentLevels))
+		for component, level := range p.componentLevels {
+			components = append(components, component+"="+level)
+		}
+		sort.Strings(components)
+		stages = append(stages, "component-levels:"+strings.Join(components, ","))
+	}
+
+	if time.Now().Before(p.quietUntil) {
+		stages = append(stages, "quiet")
+	}
+
+	if p.sampler != nil {
+		stages = append(stages, p.sampler.describe())
+	}
+
+	if p.dedup != nil {
+		stages = append(stages, p.dedup.describe())
+	}
+
+	for i := range p.ctxEnrichers {
+		stages = append(stages, fmt.Sprintf("context-enricher:%d", i+1))
+	}
+
+	if len(p.globalFields) > 0 {
+		stages = append(stages, "global-fields")
+	}
+
+	var levels []string
+	for lvl, fields := range p.levelFields {
+		if len(fields) > 0 {
+			levels = append(levels, lvl)
+		}
+	}
+	sort.Strings(levels)
+	for _, lvl := range levels {
+		stages = append(stages, "level-fields:"+lvl)
+	}
+
+	if p.settings.reportCaller {
+		stages = append(stages, "source")
+	}
+
+	for i := range p.enrichers {
+		stages = append(stages, fmt.Sprintf("enricher:%d", i+1))
+	}
+
+	for _, st := range p.builtins {
+		stages = append(stages, st.name)
+	}
+
+	for i := range p.filters {
+		stages = append(stages, fmt.Sprintf("filter:%d", i+1))
+	}
+
+	if p.settings.maxMsgLen > 0 {
+		stages = append(stages, fmt.Sprintf("max-msg-len:%d", p.settings.maxMsgLen))
+	}
+
+	for i := range p.hooks {
+		stages = append(stages, fmt.Sprintf("hook:%d", i+1))
+	}
+	return stages
+}
+
+// resolveLazy returns the fields with the values of any Lazy
+// fields replaced by the result of calling their function
+func resolveLazy(fields []Field) []Field {
+	var resolved []Field
+	for i, f := range fields {
+		fn, ok := f.Val.(lazyValue)
+		if !ok {
+			continue
+		}
+
+		if resolved == nil {
+			resolved = make([]Field, len(fields))
+			copy(resolved, fields)
+		}
+		resolved[i].Val = fn()
+	}
+
+	if resolved == nil {
+		return fields
+	}
+	return resolved
+}
+
+// prependFields returns the given fields preceded by the
+// extra ones, so that the given fields win when mapified
+func prependFields(extra, fields []Field) []Field {
+	if len(extra) == 0 {
+		return fields
+	}
+
+	merged := make([]Field, 0, len(extra)+len(fields))
+	merged = append(merged, extra...)
+	return append(merged, fields...)
+}
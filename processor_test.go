@@ -0,0 +1,333 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnricherChain(t *testing.T) {
+	l, err := NewMemoryLogger(&Config{ReportCaller: new(bool)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var order []string
+	l.AddEnricher(func(fields []Field) []Field {
+		order = append(order, "first")
+		return append(fields, Str("chain", "first"))
+	})
+	l.AddEnricher(func(fields []Field) []Field {
+		order = append(order, "second")
+
+		// The second enricher sees, and may rewrite, the first one's fields
+		for i, f := range fields {
+			if f.Name == "chain" {
+				fields[i].Val = f.Val.(string) + ",second"
+			}
+		}
+		return fields
+	})
+
+	l.Info("msg", Str("k", "v"))
+
+	if got := strings.Join(order, ","); got != "first,second" {
+		t.Errorf("got enrichers called in order %s, want first,second", got)
+	}
+
+	entry, _ := l.LastEntry()
+	if got, _ := entry.Field("chain"); got != "first,second" {
+		t.Errorf("got chain field %v, want first,second", got)
+	}
+	if got, _ := entry.Field("k"); got != "v" {
+		t.Errorf("got k field %v, want v", got)
+	}
+}
+
+func TestQuiet(t *testing.T) {
+	l, err := NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	levels := func() string {
+		var got []string
+		for _, e := range l.Entries() {
+			got = append(got, e.Level)
+		}
+		l.Reset()
+		return strings.Join(got, ",")
+	}
+
+	logAll := func() {
+		l.Debug("debug")
+		l.Info("info")
+		l.Warn("warn")
+		l.Error("error")
+	}
+
+	l.Quiet(50 * time.Millisecond)
+	logAll()
+	if got := levels(); got != "error" {
+		t.Errorf("during the quiet window: got levels %s, want error", got)
+	}
+	if l.IsEnabled("info") {
+		t.Error("info reported enabled during the quiet window")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	logAll()
+	if got := levels(); got != "debug,info,warn,error" {
+		t.Errorf("after the quiet window: got levels %s, want all", got)
+	}
+
+	l.Quiet(time.Hour)
+	l.Unquiet()
+	logAll()
+	if got := levels(); got != "debug,info,warn,error" {
+		t.Errorf("after Unquiet: got levels %s, want all", got)
+	}
+}
+
+func TestSetLevelDefaultFields(t *testing.T) {
+	l, err := NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.SetLevelDefaultFields("error", Str("alert", "pager"), Str("team", "core")); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.SetLevelDefaultFields("bogus", Str("k", "v")); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+
+	l.Error("failed")
+	entry, _ := l.LastEntry()
+	if got, _ := entry.Field("alert"); got != "pager" {
+		t.Errorf("error entry: got alert field %v, want pager", got)
+	}
+
+	l.Error("failed", Str("team", "storage"))
+	entry, _ = l.LastEntry()
+	if got, _ := entry.Field("team"); got != "storage" {
+		t.Errorf("error entry: got team field %v, want the per-call storage", got)
+	}
+
+	l.Info("fine")
+	entry, _ = l.LastEntry()
+	if got, ok := entry.Field("alert"); ok {
+		t.Errorf("info entry: got alert field %v, want none", got)
+	}
+
+	l.WithFields(Str("k", "v")).Error("child")
+	entry, _ = l.LastEntry()
+	if got, _ := entry.Field("alert"); got != "pager" {
+		t.Errorf("child error entry: got alert field %v, want pager", got)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	l, err := NewClient("logrus", &Config{
+		OutFormat:    "json",
+		LogLevel:     "warn",
+		Writer:       ioutil.Discard,
+		ReportCaller: new(bool),
+		GlobalFields: []Field{Str("service", "api")},
+		MaskKeys:     map[string]MaskSpec{"password": {}},
+		Sampling:     &SamplingConfig{Initial: 10, Thereafter: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	l.AddEnricher(func(fields []Field) []Field { return fields })
+	l.AddFilter(func(level, msg string, fields []Field) (string, []Field, bool) {
+		return msg, fields, true
+	})
+	l.AddHook(&recordingHook{})
+
+	want := []string{
+		"level:warn",
+		"sample:10/100",
+		"global-fields",
+		"enricher:1",
+		"mask:password",
+		"prefix-clashes:level,msg,time",
+		"filter:1",
+		"hook:1",
+		"format:json",
+		"output:writer",
+	}
+	if got := l.Pipeline(); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got pipeline %q, want %q", got, want)
+	}
+}
+
+func TestGlobalFields(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		var buf bytes.Buffer
+		l, err := NewClient(name, &Config{
+			Writer:       &buf,
+			OutFormat:    "json",
+			GlobalFields: []Field{Str("service", "checkout"), Str("version", "1.4.2")},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l.Info("plain")
+		l.Info("override", Str("version", "2.0.0"))
+		l.WithFields(Str("service", "child")).Info("bound")
+
+		want := []map[string]string{
+			{"service": "checkout", "version": "1.4.2"},
+			{"service": "checkout", "version": "2.0.0"},
+			{"service": "child", "version": "1.4.2"},
+		}
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != len(want) {
+			t.Fatalf("%s: got %d lines, want %d", name, len(lines), len(want))
+		}
+		for i, line := range lines {
+			var got map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &got); err != nil {
+				t.Fatalf("%s: invalid json %q: %v", name, line, err)
+			}
+			for k, v := range want[i] {
+				if got[k] != v || strings.Count(line, `"`+k+`"`) != 1 {
+					t.Errorf("%s line %d: got %s %v in %s, want it once as %s", name, i, k, got[k], line, v)
+				}
+			}
+		}
+	}
+}
+
+func TestLazy(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog", "memory"} {
+		var buf bytes.Buffer
+		l, err := NewClient(name, &Config{Writer: &buf, OutFormat: "json", LogLevel: "info"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		calls := 0
+		expensive := Lazy("expensive", func() interface{} {
+			calls++
+			return "computed"
+		})
+
+		l.Debug("suppressed", expensive)
+		l.WithFields(Str("child", "yes")).Debug("suppressed", expensive)
+		if calls != 0 {
+			t.Errorf("%s: got %d calls for suppressed entries, want none", name, calls)
+		}
+
+		l.Info("emitted", expensive)
+		if calls != 1 {
+			t.Errorf("%s: got %d calls for an emitted entry, want 1", name, calls)
+		}
+
+		if m, ok := l.(*MemoryLogger); ok {
+			entry, _ := m.LastEntry()
+			if got, _ := entry.Field("expensive"); got != "computed" {
+				t.Errorf("%s: got expensive field %v, want computed", name, got)
+			}
+		} else if !strings.Contains(buf.String(), `"expensive":"computed"`) {
+			t.Errorf("%s: got %q, want the computed value", name, buf.String())
+		}
+	}
+}
+
+func TestFilters(t *testing.T) {
+	l, err := NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Drop the noisy messages
+	l.AddFilter(func(level, msg string, fields []Field) (string, []Field, bool) {
+		return msg, fields, !strings.Contains(msg, "noisy")
+	})
+
+	// Rewrite the user field, and the message
+	l.AddFilter(func(level, msg string, fields []Field) (string, []Field, bool) {
+		for i, f := range fields {
+			if f.Name == "user" {
+				fields[i].Val = "anonymous"
+			}
+		}
+		return "filtered: " + msg, fields, true
+	})
+
+	l.Info("a noisy message", Str("user", "bob"))
+	l.Warn("kept", Str("user", "bob"), Int("n", 1))
+
+	entries := l.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want the noisy one dropped", len(entries))
+	}
+
+	e := entries[0]
+	if e.Msg != "filtered: kept" || e.Level != "warn" {
+		t.Errorf("got %s %q, want warn \"filtered: kept\"", e.Level, e.Msg)
+	}
+	if got, _ := e.Field("user"); got != "anonymous" {
+		t.Errorf("got user field %v, want anonymous", got)
+	}
+	if got, _ := e.Field("n"); got != 1 {
+		t.Errorf("got n field %v, want 1", got)
+	}
+}
+
+func TestNamedComponents(t *testing.T) {
+	l, err := NewMemoryLogger(&Config{
+		LogLevel:        "info",
+		ComponentLevels: map[string]string{"db": "debug", "http": "error"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := l.Named("db")
+	http := l.Named("http")
+	cache := l.Named("cache")
+
+	db.Debug("db debug")
+	http.Warn("http warn")
+	http.Error("http error")
+	cache.Debug("cache debug")
+	cache.Info("cache info")
+	db.WithFields(Str("table", "users")).Debug("db child debug")
+
+	want := map[string]string{
+		"db debug":       "db",
+		"http error":     "http",
+		"cache info":     "cache",
+		"db child debug": "db",
+	}
+
+	entries := l.Entries()
+	if len(entries) != len(want) {
+		t.Errorf("got %d entries, want %d", len(entries), len(want))
+	}
+	for _, e := range entries {
+		component, ok := want[e.Msg]
+		if !ok {
+			t.Errorf("got %q logged, want it filtered by its component level", e.Msg)
+			continue
+		}
+		if got, _ := e.Field("component"); got != component {
+			t.Errorf("%s: got component %v, want %s", e.Msg, got, component)
+		}
+	}
+
+	if !db.IsEnabled("debug") || http.IsEnabled("warn") || cache.IsEnabled("debug") {
+		t.Error("IsEnabled does not follow the component levels")
+	}
+}
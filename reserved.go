@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"sort"
+	"strings"
+)
+
+// clashPrefix is prepended to the name of fields clashing with the keys
+// the output format writes itself, as done by the logrus formatters
+const clashPrefix = "fields."
+
+// reservedKeys returns the keys which the output format described by
+// the given Config writes for the timestamp, level and message, and
+// which fields must therefore not use
+func reservedKeys(cfg *Config) []string {
+	switch cfg.OutFormat {
+	case "apache":
+		return nil
+	case "ecs":
+		return []string{"@timestamp", "ecs.version", "log.level", "message"}
+	}
+
+	keys := []string{"time", "level", "msg"}
+	for i, key := range []string{cfg.FieldKeyTime, cfg.FieldKeyLevel, cfg.FieldKeyMsg} {
+		if key != "" {
+			keys[i] = key
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// prefixClashes returns an Enricher that renames any field using one of
+// the given reserved keys to fields.<name>, so that it is neither lost
+// nor duplicated in the output
+func prefixClashes(keys []string) Enricher {
+	reserved := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		reserved[key] = true
+	}
+
+	return func(fields []Field) []Field {
+		var renamed []Field
+		for i, f := range fields {
+			if !reserved[f.Name] {
+				continue
+			}
+
+			if renamed == nil {
+				renamed = make([]Field, len(fields))
+				copy(renamed, fields)
+			}
+			renamed[i].Name = clashPrefix + f.Name
+		}
+
+		if renamed == nil {
+			return fields
+		}
+		return renamed
+	}
+}
+
+// describeClashes returns the stage name for prefixClashes
+func describeClashes(keys []string) string {
+	return "prefix-clashes:" + strings.Join(keys, ",")
+}
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestReservedFieldNames(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		var buf bytes.Buffer
+		l, err := NewClient(name, &Config{Writer: &buf, OutFormat: "json"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l.Info("real message", Str("msg", "user msg"), Str("level", "user level"), Str("time", "user time"))
+
+		line := buf.String()
+		var got map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("%s: invalid json %q: %v", name, line, err)
+		}
+
+		if got["msg"] != "real message" || got["level"] != "info" {
+			t.Errorf("%s: got msg %v and level %v, want those of the entry", name, got["msg"], got["level"])
+		}
+		for _, key := range []string{"msg", "level", "time"} {
+			if strings.Count(line, `"`+key+`"`) != 1 {
+				t.Errorf("%s: got the %s key more than once in %s", name, key, line)
+			}
+			if got["fields."+key] != "user "+key {
+				t.Errorf("%s: got fields.%s %v, want the user field", name, key, got["fields."+key])
+			}
+		}
+	}
+
+	// Renamed keys are reserved in their place
+	l, err := NewMemoryLogger(&Config{FieldKeyMsg: "message"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("real message", Str("msg", "kept"), Str("message", "clash"))
+	entry, _ := l.LastEntry()
+	if got, _ := entry.Field("msg"); got != "kept" {
+		t.Errorf("got msg field %v, want it kept once msg is not reserved", got)
+	}
+	if got, _ := entry.Field("fields.message"); got != "clash" {
+		t.Errorf("got fields.message %v, want the clashing field renamed", got)
+	}
+}
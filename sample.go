@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SamplingConfig throttles repeated log lines: within each second, the
+// first Initial entries with a given level and message are logged, and
+// thereafter only every Thereafter-th one (none, if zero)
+type SamplingConfig struct {
+	Initial    int `json:"initial" yaml:"initial"`
+	Thereafter int `json:"thereafter" yaml:"thereafter"`
+}
+
+// sampleKey identifies the entries counted together when sampling
+type sampleKey struct {
+	level string
+	msg   string
+}
+
+// sampler counts the entries per level and message in the current
+// one second window, deciding which of them to log
+type sampler struct {
+	initial    int
+	thereafter int
+
+	mu     sync.Mutex
+	window time.Time
+	counts map[sampleKey]int
+}
+
+func newSampler(cfg *SamplingConfig) *sampler {
+	return &sampler{
+		initial:    cfg.Initial,
+		thereafter: cfg.Thereafter,
+	}
+}
+
+// allow reports if the entry with the given level and message should be
+// logged, counting it against the current window
+func (s *sampler) allow(level, msg string) bool {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.window) >= time.Second {
+		s.window = now
+		s.counts = map[sampleKey]int{}
+	}
+
+	key := sampleKey{level, msg}
+	n := s.counts[key] + 1
+	s.counts[key] = n
+
+	if n <= s.initial {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.initial)%s.thereafter == 0
+}
+
+// describe returns the sampling stage description for Pipeline
+func (s *sampler) describe() string {
+	return fmt.Sprintf("sample:%d/%d", s.initial, s.thereafter)
+}
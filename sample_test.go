@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSampling(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := NewClient(name, &Config{
+				Writer:   &buf,
+				Sampling: &SamplingConfig{Initial: 10, Thereafter: 100},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < 1000; i++ {
+				l.Info("repeated")
+			}
+			l.Warn("repeated")
+			l.Info("other")
+
+			// 10 initial and every 100th of the other 990, unless the
+			// loop spans a one second window, which restarts the count
+			got := strings.Count(buf.String(), "repeated")
+			if got < 20 || got > 40 {
+				t.Errorf("got %d entries written, want about 20", got)
+			}
+
+			if !strings.Contains(buf.String(), "other") {
+				t.Error("a different message was sampled with the repeated one")
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// InstallSignalLevelToggle starts a background goroutine that, on each
+// receipt of the given signal, moves the package-level logger on to the
+// next of the given levels, wrapping around at the end of the list. For
+// example, passing syscall.SIGUSR1, "info" and "debug" toggles between
+// the two. If the current level is not in the list, the first is used.
+//
+// Call the returned function to stop listening for the signal, which
+// waits for the goroutine to exit. It is safe to call more than once.
+// Signals not supported by the platform, as most are on Windows, are
+// never received and so are ignored.
+func InstallSignalLevelToggle(sig os.Signal, levels ...string) (stop func()) {
+	var (
+		sigs    = make(chan os.Signal, 1)
+		done    = make(chan struct{})
+		stopped = make(chan struct{})
+		once    sync.Once
+	)
+
+	signal.Notify(sigs, sig)
+
+	go func() {
+		defer close(stopped)
+		defer signal.Stop(sigs)
+		for {
+			select {
+			case <-done:
+				return
+			case <-sigs:
+				toggleLevel(levels)
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() { close(done) })
+		<-stopped
+	}
+}
+
+// toggleLevel sets the package-level logger to the level following
+// its current one in the given list
+func toggleLevel(levels []string) {
+	if len(levels) == 0 {
+		return
+	}
+
+	next := levels[0]
+	current := GetLevel()
+	for i, name := range levels {
+		if lvl, err := parseLevel(name); err == nil && lvl == current {
+			next = levels[(i+1)%len(levels)]
+			break
+		}
+	}
+
+	if err := SetLevel(next); err != nil {
+		client().Error("unable to toggle log level", ErrField(err))
+	}
+}
@@ -0,0 +1,44 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// waitForLevel fails the test if the package-level
+// logger does not reach the given level soon
+func waitForLevel(t *testing.T, level string) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for GetLevel() != level {
+		if time.Now().After(deadline) {
+			t.Fatalf("got level %s, want %s", GetLevel(), level)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestInstallSignalLevelToggle(t *testing.T) {
+	useMemoryClient(t)
+	if err := SetLevel("info"); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := InstallSignalLevelToggle(syscall.SIGUSR1, "info", "debug")
+	defer stop()
+
+	for _, want := range []string{"debug", "info", "debug"} {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+			t.Fatal(err)
+		}
+		waitForLevel(t, want)
+	}
+
+	stop()
+	stop()
+}
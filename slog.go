@@ -0,0 +1,470 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+)
+
+// ------------------------------------------------------------------
+
+// slog levels for trace and fatal, which slog does not define
+const (
+	slogTraceLevel = slog.LevelDebug - 4
+	slogFatalLevel = slog.LevelError + 4
+)
+
+// NewSlogLogger wraps a standard library log/slog client
+func NewSlogLogger(cfg *Config) (*SlogLogger, error) {
+	cfg, err := checkedConfig(defaultConfig(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	l := newSlogLogger(&slogCore{
+		level:     &slog.LevelVar{},
+		processor: &processor{},
+	}, nil, "")
+
+	if err := l.Configure(cfg); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// newSlogLogger returns a logger on the given core,
+// binding the given fields and component
+func newSlogLogger(core *slogCore, bound []Field, component string) *SlogLogger {
+	l := &SlogLogger{slogCore: core}
+	l.emitter = emitter{
+		proc:      core.processor,
+		backend:   l,
+		bound:     bound,
+		component: component,
+	}
+	return l
+}
+
+// newSlogClient is used by NewClient and SetClient to create a SlogLogger
+func newSlogClient(cfg *Config) (Logger, error) {
+	l, err := NewSlogLogger(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// ------------------------------------------------------------------
+
+// SlogLogger defines a logger using the standard library log/slog package
+// as its backend. It honours the LogLevel, OutFormat (json | text | logfmt),
+// TimeFormat, DisableTimestamp and Outfile (including rotation and
+// AlsoStdout) Config fields, as well as those handled independently of the
+// backend, such as MaskKeys or PanicSafe.
+//
+// Caller information is reported through the usual caller fields: slog's
+// own source attribute is not enabled, so that it is not reported twice.
+type SlogLogger struct {
+	*slogCore
+	emitter
+
+	// also, if set, is a handler each entry is passed to as well,
+	// as created by ToAlso
+	also slog.Handler
+}
+
+// slogCore holds the configuration and state of a SlogLogger,
+// shared between a logger and the child loggers derived from it
+type slogCore struct {
+	level *slog.LevelVar
+
+	// mu guards the fields below, which are replaced on reconfiguration.
+	// It is held for reading while writing an entry, so that the output
+	// is not closed under it.
+	mu      sync.RWMutex
+	handler slog.Handler
+	format  *slogFormat
+	utc     bool
+	output  *output
+
+	*processor
+}
+
+// slogFormat holds the settings used to render the records
+// of a SlogLogger, which are fixed once its handlers are created
+type slogFormat struct {
+	name        string
+	timeFormat  string
+	disableTime bool
+	epochTime   bool
+	epochMillis bool
+	fieldKeys   map[string]string
+}
+
+// Name returns the name of the logger
+func (l *SlogLogger) Name() string {
+	return "slog"
+}
+
+// Path returns the full path to the logger output, or empty string if
+// logging is not going to a file
+func (l *SlogLogger) Path() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.output == nil {
+		return ""
+	}
+	return l.output.path
+}
+
+// Configure permits configuration of the logger via a Config struct.
+// It may be called while other goroutines are logging.
+func (l *SlogLogger) Configure(cfg *Config) error {
+	if cfg.Syslog != nil {
+		return fmt.Errorf("syslog output is not supported by the slog logger")
+	}
+
+	if cfg.PrettyJSON {
+		return fmt.Errorf("PrettyJSON is not supported by the slog logger")
+	}
+
+	if cfg.LinePrefixTimestamp {
+		return fmt.Errorf("LinePrefixTimestamp is not supported by the slog logger")
+	}
+
+	if cfg.ForceColors || cfg.DisableColors {
+		return fmt.Errorf("ForceColors and DisableColors are not supported by the slog logger")
+	}
+
+	level, err := l.toLogLevel(cfg.LogLevel)
+	if err != nil {
+		return err
+	}
+
+	if err := l.checkOutputFormat(cfg.OutFormat); err != nil {
+		return err
+	}
+
+	stages, err := builtinStages(cfg)
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(cfg)
+	if err != nil {
+		return err
+	}
+
+	format := &slogFormat{
+		name:        cfg.OutFormat,
+		timeFormat:  cfg.TimeFormat,
+		disableTime: cfg.DisableTimestamp,
+		epochTime:   cfg.EpochTime && cfg.OutFormat == "json",
+		epochMillis: cfg.EpochMillis,
+		fieldKeys:   slogFieldKeys(cfg),
+	}
+
+	l.processor.configure(cfg, stages, l, out.budget)
+	l.level.Set(level)
+
+	l.mu.Lock()
+	old := l.output
+	l.handler = format.outputHandler(out, l.level)
+	l.format = format
+	l.utc = cfg.UTC
+	l.output = out
+	l.mu.Unlock()
+
+	return old.close()
+}
+
+// SetLevel changes the level of the logger in place, and so also
+// of any child loggers derived from it, calling the functions
+// registered by OnLevelChange if the level changed
+func (l *SlogLogger) SetLevel(level string) error {
+	lvl, err := l.toLogLevel(level)
+	if err != nil {
+		return err
+	}
+
+	l.changeLevel(l.GetLevel, func() {
+		l.level.Set(lvl)
+	})
+	return nil
+}
+
+// GetLevel returns the canonical name of the current level of the logger
+func (l *SlogLogger) GetLevel() string {
+	return slogLevelName(l.level.Level())
+}
+
+// levelEnabled reports if the logger level lets through
+// entries at the given canonical level
+func (l *SlogLogger) levelEnabled(level string) bool {
+	return slogLevels[level] >= l.level.Level()
+}
+
+// exit closes the logger and exits the program, if so configured
+func (l *SlogLogger) exit() {
+	if !l.exitsOnFatal() {
+		return
+	}
+
+	l.Close()
+	os.Exit(1)
+}
+
+// Flush writes out any buffered entries and syncs the log file, if any,
+// leaving the logger open for further use
+func (l *SlogLogger) Flush() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.output.flush()
+}
+
+// Close writes out any buffered entries and closes the log file, if any.
+// The logger, and any derived from it, should not be used afterwards.
+func (l *SlogLogger) Close() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.output.close()
+}
+
+// write sends a single record to the slog handler
+func (l *SlogLogger) write(level, msg string, fields []Field) error {
+	attrs := slogAttrs(fields)
+
+	l.mu.RLock()
+	record := slog.NewRecord(timestamp(l.utc), slogLevels[level], msg, 0)
+	record.AddAttrs(attrs...)
+	err := l.handler.Handle(context.Background(), record)
+	l.output.flushFor(level)
+	l.mu.RUnlock()
+
+	if l.also != nil {
+		if alsoErr := l.also.Handle(context.Background(), record); err == nil {
+			err = alsoErr
+		}
+	}
+	return err
+}
+
+// derive returns a child logger sharing the core of this one
+func (l *SlogLogger) derive(bound []Field, component, ctxLevel string) Logger {
+	child := newSlogLogger(l.slogCore, bound, component)
+	child.ctxLevel = ctxLevel
+	child.also = l.also
+	return child
+}
+
+// ToAlso returns a logger that, for the calls made on it, writes each
+// formatted line to the given writer as well as to the configured output
+func (l *SlogLogger) ToAlso(w io.Writer) LogLeveler {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	child := newSlogLogger(l.slogCore, l.bound, l.component)
+	child.ctxLevel = l.ctxLevel
+	child.also = l.format.newHandler(w, l.level)
+	return child
+}
+
+// Pipeline returns an ordered description of the processing stages
+// an entry passes through on its way to the output, for debugging
+func (l *SlogLogger) Pipeline() []string {
+	stages := []string{"level:" + l.GetLevel()}
+	stages = append(stages, l.processor.stages()...)
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	stages = append(stages, "format:"+l.format.name)
+	return append(stages, l.output.describe())
+}
+
+// outputHandler creates a slog handler writing to the given output.
+// When splitting streams, entries at error level and above are handled
+// by a second handler.
+func (f *slogFormat) outputHandler(out *output, level slog.Leveler) slog.Handler {
+	handler := f.newHandler(out.out, level)
+	if out.errOut == nil {
+		return handler
+	}
+
+	return &splitHandler{
+		Handler: handler,
+		high:    f.newHandler(out.errOut, level),
+	}
+}
+
+// newHandler creates a slog handler in this format writing to w.
+// The slog text format is logfmt, so serves for both text and logfmt.
+func (f *slogFormat) newHandler(w io.Writer, level slog.Leveler) slog.Handler {
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: f.replaceAttr,
+	}
+
+	if f.name == "json" {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+func (l *SlogLogger) checkOutputFormat(name string) error {
+	switch name {
+	case "json", "text", "logfmt":
+		return nil
+	default:
+		return fmt.Errorf(
+			"unknown formatter %s. Legal: json | text | logfmt",
+			name,
+		)
+	}
+}
+
+// slogLevels maps the canonical level names to the slog levels
+var slogLevels = map[string]slog.Level{
+	levelTrace: slogTraceLevel,
+	levelDebug: slog.LevelDebug,
+	levelInfo:  slog.LevelInfo,
+	levelWarn:  slog.LevelWarn,
+	levelError: slog.LevelError,
+	levelFatal: slogFatalLevel,
+}
+
+func (l *SlogLogger) toLogLevel(name string) (slog.Level, error) {
+	name, err := parseLevel(name)
+	if err != nil {
+		return 0, err
+	}
+	return slogLevels[name], nil
+}
+
+// slogLevelName returns the canonical name of the given slog level
+func slogLevelName(level slog.Level) string {
+	switch level {
+	case slogTraceLevel:
+		return levelTrace
+	case slog.LevelDebug:
+		return levelDebug
+	case slog.LevelInfo:
+		return levelInfo
+	case slog.LevelWarn:
+		return levelWarn
+	case slog.LevelError:
+		return levelError
+	case slogFatalLevel:
+		return levelFatal
+	default:
+		return level.String()
+	}
+}
+
+// replaceAttr renders the level attribute using the canonical names,
+// and the time attribute in the configured format, if any, or drops it
+// if timestamps are disabled
+func (f *slogFormat) replaceAttr(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return attr
+	}
+
+	switch attr.Key {
+	case slog.LevelKey:
+		if level, ok := attr.Value.Any().(slog.Level); ok {
+			attr.Value = slog.StringValue(slogLevelName(level))
+		}
+	case slog.TimeKey:
+		if f.disableTime {
+			return slog.Attr{}
+		}
+		switch {
+		case attr.Value.Kind() != slog.KindTime:
+		case f.epochTime:
+			attr.Value = slog.Int64Value(epochValue(attr.Value.Time(), f.epochMillis))
+		case f.timeFormat != "":
+			attr.Value = slog.StringValue(attr.Value.Time().Format(f.timeFormat))
+		}
+	}
+
+	if key, ok := f.fieldKeys[attr.Key]; ok {
+		attr.Key = key
+	}
+	return attr
+}
+
+// slogFieldKeys maps the slog built-in keys to those
+// set by the Config FieldKey fields, if any
+func slogFieldKeys(cfg *Config) map[string]string {
+	keys := map[string]string{}
+	if cfg.FieldKeyMsg != "" {
+		keys[slog.MessageKey] = cfg.FieldKeyMsg
+	}
+
+	if cfg.FieldKeyLevel != "" {
+		keys[slog.LevelKey] = cfg.FieldKeyLevel
+	}
+
+	if cfg.FieldKeyTime != "" {
+		keys[slog.TimeKey] = cfg.FieldKeyTime
+	}
+	return keys
+}
+
+// splitHandler is a slog handler passing records at error level and
+// above to a second handler, used to split the output between stdout
+// and stderr
+type splitHandler struct {
+	slog.Handler
+	high slog.Handler
+}
+
+// Handle passes the record to the handler for its level
+func (h *splitHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level >= slog.LevelError {
+		return h.high.Handle(ctx, record)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs adds the attributes to both handlers
+func (h *splitHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &splitHandler{
+		Handler: h.Handler.WithAttrs(attrs),
+		high:    h.high.WithAttrs(attrs),
+	}
+}
+
+// WithGroup adds the group to both handlers
+func (h *splitHandler) WithGroup(name string) slog.Handler {
+	return &splitHandler{
+		Handler: h.Handler.WithGroup(name),
+		high:    h.high.WithGroup(name),
+	}
+}
+
+// ------------------------------------------------------------------
+
+// slogAttrs converts the slice of Fields into slog attributes, sorted by
+// name. As with mapify, the last of any fields sharing a name wins.
+func slogAttrs(fields []Field) []slog.Attr {
+	data := mapify(fields...)
+
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	attrs := make([]slog.Attr, len(names))
+	for i, name := range names {
+		attrs[i] = slog.Any(name, data[name])
+	}
+	releaseMap(data)
+	return attrs
+}
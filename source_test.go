@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+// failCallerLookup makes the caller lookup find no frames
+// for the duration of the test
+func failCallerLookup(t *testing.T) {
+	t.Helper()
+
+	saved := callers
+	callers = func(int, []uintptr) int { return 0 }
+	t.Cleanup(func() { callers = saved })
+}
+
+func TestUnknownSource(t *testing.T) {
+	failCallerLookup(t)
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want map[string]interface{}
+	}{
+		{"placeholders", Config{}, map[string]interface{}{"func": "???", "file": "???", "line": 0}},
+		{"combined placeholders", Config{CombinedSource: true}, map[string]interface{}{"pkg": "???", "src": "???:0"}},
+		{"omitted", Config{OmitUnknownSource: true}, nil},
+		{"combined omitted", Config{CombinedSource: true, OmitUnknownSource: true}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := NewMemoryLogger(&tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			l.Info("msg")
+			entry, _ := l.LastEntry()
+			for name, want := range tt.want {
+				if got, ok := entry.Field(name); !ok || got != want {
+					t.Errorf("got %s field %v, want %v", name, got, want)
+				}
+			}
+
+			if tt.want == nil {
+				for _, name := range []string{"func", "file", "line", "pkg", "src"} {
+					if got, ok := entry.Field(name); ok {
+						t.Errorf("got %s field %v, want it omitted", name, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSuppressedSkipsCallerLookup(t *testing.T) {
+	lookups := 0
+	saved := callers
+	callers = func(skip int, pcs []uintptr) int {
+		lookups++
+		return saved(skip+1, pcs)
+	}
+	t.Cleanup(func() { callers = saved })
+
+	for _, name := range []string{"logrus", "zap", "slog", "memory"} {
+		l, err := NewClient(name, &Config{LogLevel: "info", Writer: ioutil.Discard})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lookups = 0
+		l.Debug("suppressed")
+		l.WithFields(Str("child", "yes")).Trace("suppressed")
+		if lookups != 0 {
+			t.Errorf("%s: got %d caller lookups for suppressed entries, want none", name, lookups)
+		}
+
+		l.Info("emitted")
+		if lookups == 0 {
+			t.Errorf("%s: got no caller lookup for an emitted entry", name)
+		}
+	}
+}
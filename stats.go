@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// StartRuntimeStats launches a background goroutine that logs, at info level
+// on every tick of the given interval, the current number of goroutines,
+// heap usage and GC count. Call the returned function to stop reporting.
+// A non-positive interval reports nothing.
+func StartRuntimeStats(interval time.Duration) (cancel func()) {
+	return runEvery(interval, logRuntimeStats)
+}
+
+// runEvery calls fn on every tick of the given interval in a background
+// goroutine. The returned function stops the ticker and waits for the
+// goroutine to exit. It is safe to call more than once. A non-positive
+// interval, which time.NewTicker panics on, never calls fn.
+func runEvery(interval time.Duration, fn func()) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+
+	var (
+		ticker  = time.NewTicker(interval)
+		done    = make(chan struct{})
+		stopped = make(chan struct{})
+		once    sync.Once
+	)
+
+	go func() {
+		defer close(stopped)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				fn()
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() { close(done) })
+		<-stopped
+	}
+}
+
+// logRuntimeStats emits a single line of runtime statistics
+func logRuntimeStats() {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	client().Info(
+		"runtime stats",
+		F("goroutines", runtime.NumGoroutine()),
+		F("heap_alloc", mem.HeapAlloc),
+		F("heap_inuse", mem.HeapInuse),
+		F("heap_objects", mem.HeapObjects),
+		F("num_gc", mem.NumGC),
+	)
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"testing"
+	"time"
+)
+
+// waitForEntry waits up to a second for the logger to record
+// an entry with the given message, returning it
+func waitForEntry(t *testing.T, l *MemoryLogger, msg string) Entry {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		for _, e := range l.Entries() {
+			if e.Msg == msg {
+				return e
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatalf("no %q entry logged within a second", msg)
+	return Entry{}
+}
+
+func TestStartRuntimeStats(t *testing.T) {
+	l := useMemoryClient(t)
+
+	cancel := StartRuntimeStats(10 * time.Millisecond)
+	entry := waitForEntry(t, l, "runtime stats")
+	cancel()
+	cancel()
+
+	if entry.Level != "info" {
+		t.Errorf("got level %s, want info", entry.Level)
+	}
+	if n, _ := entry.Field("goroutines"); n.(int) < 1 {
+		t.Errorf("got goroutines field %v, want at least 1", n)
+	}
+	for _, name := range []string{"heap_alloc", "heap_inuse", "heap_objects", "num_gc"} {
+		if _, ok := entry.Field(name); !ok {
+			t.Errorf("no %s field", name)
+		}
+	}
+
+	l.Reset()
+	time.Sleep(30 * time.Millisecond)
+	if n := len(l.Entries()); n != 0 {
+		t.Errorf("got %d entries after cancelling, want none", n)
+	}
+}
+
+func TestStartRuntimeStatsNonPositiveInterval(t *testing.T) {
+	l := useMemoryClient(t)
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		cancel := StartRuntimeStats(interval)
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+		cancel()
+	}
+
+	if n := len(l.Entries()); n != 0 {
+		t.Errorf("got %d entries, want none", n)
+	}
+}
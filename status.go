@@ -0,0 +1,23 @@
+package logging
+
+// statusLevel returns the level to log an HTTP status code at:
+// error for server errors (5xx), warn for client errors (4xx)
+// and info for anything else
+func statusLevel(status int) string {
+	switch {
+	case status >= 500:
+		return levelError
+	case status >= 400:
+		return levelWarn
+	default:
+		return levelInfo
+	}
+}
+
+// logStatus logs the message via the method of l for the level of the
+// given HTTP status code, with the code as the status field
+func logStatus(l LogLeveler, status int, msg string, fields []Field) {
+	fields = append(fields[:len(fields):len(fields)], Int("status", status))
+	log, _ := levelMethod(l, statusLevel(status))
+	log(msg, fields...)
+}
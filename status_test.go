@@ -0,0 +1,60 @@
+package logging
+
+import "testing"
+
+func TestLogStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		level  string
+	}{
+		{200, "info"},
+		{302, "info"},
+		{399, "info"},
+		{400, "warn"},
+		{499, "warn"},
+		{500, "error"},
+		{503, "error"},
+	}
+
+	l, err := NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		l.Reset()
+		l.LogStatus(tt.status, "served", Str("path", "/"))
+
+		entry, ok := l.LastEntry()
+		if !ok {
+			t.Fatalf("status %d: nothing logged", tt.status)
+		}
+
+		if entry.Level != tt.level {
+			t.Errorf("status %d: got level %s, want %s", tt.status, entry.Level, tt.level)
+		}
+
+		if got, _ := entry.Field("status"); got != tt.status {
+			t.Errorf("status %d: got status field %v", tt.status, got)
+		}
+
+		if got, _ := entry.Field("path"); got != "/" {
+			t.Errorf("status %d: got path field %v, want /", tt.status, got)
+		}
+	}
+}
+
+func TestLogStatusKeepsCallerFields(t *testing.T) {
+	l, err := NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := make([]Field, 1, 2)
+	fields[0] = Str("a", "b")
+	l.LogStatus(200, "served", fields...)
+
+	if extra := fields[:2]; extra[1].Name != "" {
+		t.Errorf("LogStatus wrote %v into the caller's slice", extra[1])
+	}
+}
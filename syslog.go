@@ -0,0 +1,22 @@
+package logging
+
+// SyslogConfig describes the syslog daemon to which log entries are
+// sent, in addition to the configured output unless Only is set.
+// It is supported by the logrus backend, on platforms providing syslog.
+type SyslogConfig struct {
+	// Network and Address of the syslog daemon, e.g. "udp" and
+	// "logs.example.com:514". If both are empty the local daemon is used.
+	Network string `json:"network" yaml:"network"`
+	Address string `json:"address" yaml:"address"`
+
+	// Facility is the syslog facility name: kern, user, mail, daemon,
+	// auth, syslog, lpr, news, uucp, cron, authpriv, ftp or local0 to
+	// local7. Defaults to user.
+	Facility string `json:"facility" yaml:"facility"`
+
+	// Tag is the syslog tag. Defaults to the program name.
+	Tag string `json:"tag" yaml:"tag"`
+
+	// Only sends entries to syslog alone, discarding the file/stdout output
+	Only bool `json:"only" yaml:"only"`
+}
@@ -0,0 +1,66 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	logrus_syslog "github.com/sirupsen/logrus/hooks/syslog"
+)
+
+// syslogFacilities maps the facility names to their syslog priority
+var syslogFacilities = map[string]syslog.Priority{
+	"kern":     syslog.LOG_KERN,
+	"user":     syslog.LOG_USER,
+	"mail":     syslog.LOG_MAIL,
+	"daemon":   syslog.LOG_DAEMON,
+	"auth":     syslog.LOG_AUTH,
+	"syslog":   syslog.LOG_SYSLOG,
+	"lpr":      syslog.LOG_LPR,
+	"news":     syslog.LOG_NEWS,
+	"uucp":     syslog.LOG_UUCP,
+	"cron":     syslog.LOG_CRON,
+	"authpriv": syslog.LOG_AUTHPRIV,
+	"ftp":      syslog.LOG_FTP,
+	"local0":   syslog.LOG_LOCAL0,
+	"local1":   syslog.LOG_LOCAL1,
+	"local2":   syslog.LOG_LOCAL2,
+	"local3":   syslog.LOG_LOCAL3,
+	"local4":   syslog.LOG_LOCAL4,
+	"local5":   syslog.LOG_LOCAL5,
+	"local6":   syslog.LOG_LOCAL6,
+	"local7":   syslog.LOG_LOCAL7,
+}
+
+// newSyslogHook connects to the syslog daemon described by the given
+// config, returning a logrus hook sending entries to it and the closer
+// for the connection. The hook maps the levels to syslog severities:
+// fatal to crit, error to err, warn to warning, info to info, and
+// debug and trace to debug.
+func newSyslogHook(cfg *SyslogConfig) (logrus.Hook, io.Closer, error) {
+	name := strings.ToLower(strings.TrimSpace(cfg.Facility))
+	if name == "" {
+		name = "user"
+	}
+
+	facility, ok := syslogFacilities[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown syslog facility: %s", cfg.Facility)
+	}
+
+	hook, err := logrus_syslog.NewSyslogHook(
+		cfg.Network,
+		cfg.Address,
+		facility|syslog.LOG_INFO,
+		cfg.Tag,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to connect to syslog: %v", err)
+	}
+
+	return hook, hook.Writer, nil
+}
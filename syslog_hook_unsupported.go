@@ -0,0 +1,15 @@
+//go:build windows || plan9
+
+package logging
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sirupsen/logrus"
+)
+
+// newSyslogHook reports that syslog is not available on this platform
+func newSyslogHook(cfg *SyslogConfig) (logrus.Hook, io.Closer, error) {
+	return nil, nil, fmt.Errorf("syslog is not supported on this platform")
+}
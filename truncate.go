@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// limitLength returns an Enricher that truncates the fmt.Sprint form of
+// any field value longer than maxLen characters, as for truncateString.
+// Values within the limit are left unchanged.
+func limitLength(maxLen int) Enricher {
+	return func(fields []Field) []Field {
+		limited := make([]Field, len(fields))
+		for i, f := range fields {
+			if f.Val != nil {
+				if s, ok := truncateString(fmt.Sprint(f.Val), maxLen); ok {
+					f.Val = s
+				}
+			}
+			limited[i] = f
+		}
+		return limited
+	}
+}
+
+// truncateString cuts the given string down to its first maxLen
+// characters, followed by an ellipsis and its original length, reporting
+// if it did so. Strings within the limit, or any if maxLen is not
+// positive, are returned unchanged.
+func truncateString(s string, maxLen int) (string, bool) {
+	if maxLen <= 0 || len(s) <= maxLen {
+		return s, false
+	}
+
+	count := utf8.RuneCountInString(s)
+	if count <= maxLen {
+		return s, false
+	}
+
+	cut := 0
+	for i := 0; i < maxLen; i++ {
+		_, size := utf8.DecodeRuneInString(s[cut:])
+		cut += size
+	}
+	return fmt.Sprintf("%s...(%d chars)", s[:cut], count), true
+}
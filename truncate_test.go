@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		s      string
+		maxLen int
+		want   string
+		cut    bool
+	}{
+		{"short", 10, "short", false},
+		{"exactly10!", 10, "exactly10!", false},
+		{"a longer value", 8, "a longer...(14 chars)", true},
+		{"héllo wörld", 5, "héllo...(11 chars)", true},
+		{"héllo", 5, "héllo", false},
+		{"anything", 0, "anything", false},
+	}
+
+	for _, tt := range tests {
+		got, cut := truncateString(tt.s, tt.maxLen)
+		if got != tt.want || cut != tt.cut {
+			t.Errorf("truncateString(%q, %d): got %q, %v, want %q, %v", tt.s, tt.maxLen, got, cut, tt.want, tt.cut)
+		}
+	}
+}
+
+func TestMaxLengths(t *testing.T) {
+	l, err := NewMemoryLogger(&Config{MaxFieldLen: 10, MaxMsgLen: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := strings.Repeat("x", 100)
+	l.Info("a message well over the limit", Str("body", body), Str("short", "kept"), Int("n", 12345678901))
+
+	entry, _ := l.LastEntry()
+	if want := "a message we...(29 chars)"; entry.Msg != want {
+		t.Errorf("got msg %q, want %q", entry.Msg, want)
+	}
+	if got, _ := entry.Field("body"); got != "xxxxxxxxxx...(100 chars)" {
+		t.Errorf("got body %v, want it truncated", got)
+	}
+	if got, _ := entry.Field("short"); got != "kept" {
+		t.Errorf("got short %v, want it untouched", got)
+	}
+	if got, _ := entry.Field("n"); got != "1234567890...(11 chars)" {
+		t.Errorf("got n %v, want its string form truncated", got)
+	}
+
+	l.Info("short msg", Int("small", 1))
+	entry, _ = l.LastEntry()
+	if got, _ := entry.Field("small"); entry.Msg != "short msg" || got != 1 {
+		t.Errorf("got %q with small %v, want both untouched", entry.Msg, got)
+	}
+}
@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+// levelWriter is an io.Writer logging each line written to it
+// as a message at a given level
+type levelWriter struct {
+	log func(string, ...Field)
+	err error // for an unknown level, returned by every Write
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// newLevelWriter returns a writer logging each line via the method of l
+// for the given level. Levels other than trace, debug, info, warn and
+// error (or warning) are reported once to stderr, and every Write to the
+// writer fails: in particular, fatal is not supported, as it would exit
+// on the first line.
+func newLevelWriter(l LogLeveler, level string) io.Writer {
+	log, err := levelMethod(l, level)
+	if err != nil {
+		reportUnknownLevel("Writer", level, err)
+		return &levelWriter{err: err}
+	}
+	return &levelWriter{log: log}
+}
+
+// StdLogger returns a standard library *log.Logger whose output is logged
+// line by line at info level by the package-level logger, whichever it is
+// at the time. Its flags are 0, as the logger adds its own timestamp and
+// caller. To route the standard logger through this package, use:
+//
+//	log.SetFlags(0)
+//	log.SetOutput(logging.StdLogger().Writer())
+func StdLogger() *log.Logger {
+	w := &levelWriter{
+		log: func(msg string, fields ...Field) {
+			client().Info(msg, fields...)
+		},
+	}
+	return log.New(w, "", 0)
+}
+
+// levelMethod returns the method of l logging at the given level. Levels
+// other than trace, debug, info, warn and error (or warning), including
+// fatal, map to the Info method, along with the error from parseLevel.
+func levelMethod(l LogLeveler, level string) (func(string, ...Field), error) {
+	lvl, err := parseLevel(level)
+
+	switch lvl {
+	case levelTrace:
+		return l.Trace, nil
+	case levelDebug:
+		return l.Debug, nil
+	case levelWarn:
+		return l.Warn, nil
+	case levelError:
+		return l.Error, nil
+	default:
+		return l.Info, err
+	}
+}
+
+// reportedLevels holds the method and level pairs
+// already reported by reportUnknownLevel
+var reportedLevels sync.Map
+
+// reportUnknownLevel writes the error for an unknown level given to the
+// named method to stderr, once only for each method and level
+func reportUnknownLevel(method, level string, err error) {
+	if _, seen := reportedLevels.LoadOrStore(method+"\x00"+level, true); !seen {
+		fmt.Fprintf(os.Stderr, "logging: %s: %v\n", method, err)
+	}
+}
+
+// sprintln formats the arguments as for fmt.Sprintln, without the newline
+func sprintln(args ...interface{}) string {
+	msg := fmt.Sprintln(args...)
+	return msg[:len(msg)-1]
+}
+
+// Write logs each complete line in p, keeping any trailing partial line
+// until the rest of it is written. Empty lines are not logged.
+func (w *levelWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		line := bytes.TrimRight(w.buf[:i], "\r")
+		if len(line) > 0 {
+			w.log(string(line))
+		}
+		w.buf = w.buf[i+1:]
+	}
+
+	if len(w.buf) == 0 {
+		w.buf = nil
+	}
+	return len(p), nil
+}
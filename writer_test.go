@@ -0,0 +1,152 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestToAlso(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		t.Run(name, func(t *testing.T) {
+			var out, extra bytes.Buffer
+			l, err := NewClient(name, &Config{Writer: &out, OutFormat: "json"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			l.ToAlso(&extra).Warn("both", Str("k", "v"))
+			l.Info("main only")
+
+			if got := extra.String(); !strings.Contains(got, "both") || !strings.Contains(got, `"k":"v"`) {
+				t.Errorf("got %q in the extra writer, want the line with its fields", got)
+			}
+			if strings.Contains(extra.String(), "main only") {
+				t.Error("a later call on the logger was written to the extra writer")
+			}
+
+			if got := out.String(); !strings.Contains(got, "both") || !strings.Contains(got, "main only") {
+				t.Errorf("got %q in the main writer, want both lines", got)
+			}
+		})
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		var extra bytes.Buffer
+		l, err := NewMemoryLogger(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l.ToAlso(&extra).Info("both")
+		if got := extra.String(); !strings.Contains(got, "msg=both") {
+			t.Errorf("got %q in the extra writer, want the logfmt line", got)
+		}
+		if n := len(l.Entries()); n != 1 {
+			t.Errorf("got %d entries recorded, want 1", n)
+		}
+	})
+}
+
+func TestStdLogger(t *testing.T) {
+	l := useMemoryClient(t)
+
+	std := StdLogger()
+	if std.Flags() != 0 {
+		t.Errorf("got flags %d, want 0", std.Flags())
+	}
+
+	std.Printf("forwarded %d", 1)
+	std.Print("first\nsecond")
+
+	entries := l.Entries()
+	want := []string{"forwarded 1", "first", "second"}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i, e := range entries {
+		if e.Msg != want[i] || e.Level != "info" {
+			t.Errorf("entry %d: got %s %q, want info %q", i, e.Level, e.Msg, want[i])
+		}
+	}
+}
+
+func TestWriterUnknownLevel(t *testing.T) {
+	streams := captureStreams(t)
+	l, err := NewMemoryLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if n, err := l.Writer("fatal").Write([]byte("not logged\n")); n != 0 || err == nil {
+			t.Errorf("got %d, %v writing at fatal, want 0 and an error", n, err)
+		}
+	}
+
+	if n := len(l.Entries()); n != 0 {
+		t.Errorf("got %d entries, want none", n)
+	}
+	if _, stderr := streams(); strings.Count(stderr, "unknown log level: fatal") != 1 {
+		t.Errorf("got stderr %q, want the fatal level reported once", stderr)
+	}
+}
+
+// failingWriter fails every write with its error
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
+
+func TestToAlsoAfterOutputError(t *testing.T) {
+	for _, name := range []string{"logrus", "zap", "slog"} {
+		t.Run(name, func(t *testing.T) {
+			failed := errors.New("output failed")
+			l, err := NewClient(name, &Config{Writer: failingWriter{failed}, OutFormat: "json"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var extra bytes.Buffer
+			also := l.ToAlso(&extra).(TryLogLeveler)
+			if err := also.TryError("still written"); !errors.Is(err, failed) {
+				t.Errorf("got error %v, want that of the output", err)
+			}
+			if !strings.Contains(extra.String(), "still written") {
+				t.Errorf("got %q in the extra writer, want the line", extra.String())
+			}
+		})
+	}
+}
+
+// failingHook fails every entry it is fired for
+type failingHook struct{ err error }
+
+func (h failingHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h failingHook) Fire(*logrus.Entry) error {
+	return h.err
+}
+
+func TestLogrusHookError(t *testing.T) {
+	var out bytes.Buffer
+	l, err := NewLogrusLogger(&Config{Writer: &out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := errors.New("hook failed")
+	l.log.Hooks.Add(failingHook{failed})
+
+	if err := l.TryInfo("still written"); !errors.Is(err, failed) {
+		t.Errorf("got error %v, want that of the hook", err)
+	}
+	if !strings.Contains(out.String(), "still written") {
+		t.Errorf("got %q in the output, want the line", out.String())
+	}
+}
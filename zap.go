@@ -0,0 +1,430 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ------------------------------------------------------------------
+
+// zapTraceLevel is the zap level used for trace, which zap does not define
+const zapTraceLevel = zapcore.DebugLevel - 1
+
+// NewZapLogger wraps a zap client
+func NewZapLogger(cfg *Config) (*ZapLogger, error) {
+	cfg, err := checkedConfig(defaultConfig(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	l := newZapLogger(&zapCore{
+		level:     zap.NewAtomicLevel(),
+		processor: &processor{},
+	}, nil, "")
+
+	if err := l.Configure(cfg); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// newZapLogger returns a logger on the given core,
+// binding the given fields and component
+func newZapLogger(core *zapCore, bound []Field, component string) *ZapLogger {
+	l := &ZapLogger{zapCore: core}
+	l.emitter = emitter{
+		proc:      core.processor,
+		backend:   l,
+		bound:     bound,
+		component: component,
+	}
+	return l
+}
+
+// ------------------------------------------------------------------
+
+// ZapLogger defines a logger using the zap package as its backend.
+// It honours the LogLevel, OutFormat (json | text), TimeFormat,
+// DisableTimestamp and Outfile (including rotation and AlsoStdout) Config
+// fields, as well as those handled independently of the backend, such as
+// MaskKeys or PanicSafe.
+type ZapLogger struct {
+	*zapCore
+	emitter
+
+	// also, if set, is a core each entry is written to as well,
+	// as created by ToAlso
+	also zapcore.Core
+}
+
+// zapCore holds the configuration and state of a ZapLogger,
+// shared between a logger and the child loggers derived from it
+type zapCore struct {
+	level zap.AtomicLevel
+
+	// mu guards the fields below, which are replaced on reconfiguration.
+	// It is held for reading while writing an entry, so that the output
+	// is not closed under it.
+	mu      sync.RWMutex
+	core    zapcore.Core
+	encoder zapcore.Encoder
+	format  string
+	utc     bool
+	output  *output
+
+	*processor
+}
+
+// Name returns the name of the logger
+func (l *ZapLogger) Name() string {
+	return "zap"
+}
+
+// Path returns the full path to the logger output, or empty string if
+// logging is not going to a file
+func (l *ZapLogger) Path() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.output == nil {
+		return ""
+	}
+	return l.output.path
+}
+
+// Configure permits configuration of the logger via a Config struct.
+// It may be called while other goroutines are logging.
+func (l *ZapLogger) Configure(cfg *Config) error {
+	if cfg.Syslog != nil {
+		return fmt.Errorf("syslog output is not supported by the zap logger")
+	}
+
+	if cfg.PrettyJSON {
+		return fmt.Errorf("PrettyJSON is not supported by the zap logger")
+	}
+
+	if cfg.LinePrefixTimestamp {
+		return fmt.Errorf("LinePrefixTimestamp is not supported by the zap logger")
+	}
+
+	if cfg.ForceColors || cfg.DisableColors {
+		return fmt.Errorf("ForceColors and DisableColors are not supported by the zap logger")
+	}
+
+	level, err := l.toLogLevel(cfg.LogLevel)
+	if err != nil {
+		return err
+	}
+
+	encoder, err := l.toOutputFormat(cfg)
+	if err != nil {
+		return err
+	}
+
+	stages, err := builtinStages(cfg)
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(cfg)
+	if err != nil {
+		return err
+	}
+
+	l.processor.configure(cfg, stages, l, out.budget)
+	l.level.SetLevel(level)
+
+	l.mu.Lock()
+	old := l.output
+	l.core = newZapOutputCore(encoder, out, l.level)
+	l.encoder = encoder
+	l.format = cfg.OutFormat
+	l.utc = cfg.UTC
+	l.output = out
+	l.mu.Unlock()
+
+	return old.close()
+}
+
+// SetLevel changes the level of the logger in place, and so also
+// of any child loggers derived from it, calling the functions
+// registered by OnLevelChange if the level changed
+func (l *ZapLogger) SetLevel(level string) error {
+	lvl, err := l.toLogLevel(level)
+	if err != nil {
+		return err
+	}
+
+	l.changeLevel(l.GetLevel, func() {
+		l.level.SetLevel(lvl)
+	})
+	return nil
+}
+
+// GetLevel returns the canonical name of the current level of the logger
+func (l *ZapLogger) GetLevel() string {
+	return zapLevelName(l.level.Level())
+}
+
+// levelEnabled reports if the logger level lets through
+// entries at the given canonical level
+func (l *ZapLogger) levelEnabled(level string) bool {
+	return l.level.Enabled(zapLevels[level])
+}
+
+// exit flushes the output and exits the program, if so configured
+func (l *ZapLogger) exit() {
+	if !l.exitsOnFatal() {
+		return
+	}
+
+	l.mu.RLock()
+	l.core.Sync()
+	l.mu.RUnlock()
+
+	l.Close()
+	os.Exit(1)
+}
+
+// Flush writes out any buffered entries and syncs the log file, if any,
+// leaving the logger open for further use
+func (l *ZapLogger) Flush() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.output.flush()
+}
+
+// Close writes out any buffered entries and closes the log file, if any.
+// The logger, and any derived from it, should not be used afterwards.
+func (l *ZapLogger) Close() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.output.close()
+}
+
+// write sends a single entry to the zap core. The core is used directly,
+// rather than via a zap.Logger, so that fatal entries do not exit before
+// all lines are written, and so that entries for a component with a
+// level below that of the logger are not dropped.
+func (l *ZapLogger) write(level, msg string, fields []Field) error {
+	zfields := zapFields(fields)
+
+	l.mu.RLock()
+	entry := zapcore.Entry{
+		Level:   zapLevels[level],
+		Time:    timestamp(l.utc),
+		Message: msg,
+	}
+	err := l.core.Write(entry, zfields)
+	l.output.flushFor(level)
+	l.mu.RUnlock()
+
+	if l.also != nil {
+		if alsoErr := l.also.Write(entry, zfields); err == nil {
+			err = alsoErr
+		}
+	}
+	return err
+}
+
+// derive returns a child logger sharing the core of this one
+func (l *ZapLogger) derive(bound []Field, component, ctxLevel string) Logger {
+	child := newZapLogger(l.zapCore, bound, component)
+	child.ctxLevel = ctxLevel
+	child.also = l.also
+	return child
+}
+
+// ToAlso returns a logger that, for the calls made on it, writes each
+// formatted line to the given writer as well as to the configured output
+func (l *ZapLogger) ToAlso(w io.Writer) LogLeveler {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	child := newZapLogger(l.zapCore, l.bound, l.component)
+	child.ctxLevel = l.ctxLevel
+	child.also = zapcore.NewCore(l.encoder.Clone(), zapcore.Lock(zapcore.AddSync(w)), l.level)
+	return child
+}
+
+// Pipeline returns an ordered description of the processing stages
+// an entry passes through on its way to the output, for debugging
+func (l *ZapLogger) Pipeline() []string {
+	stages := []string{"level:" + l.GetLevel()}
+	stages = append(stages, l.processor.stages()...)
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	stages = append(stages, "format:"+l.format)
+	return append(stages, l.output.describe())
+}
+
+// newZapOutputCore creates a zap core encoding entries with the given
+// encoder and writing them to the given output, each writer being locked
+// so that the core is safe for concurrent use
+func newZapOutputCore(encoder zapcore.Encoder, out *output, level zapcore.LevelEnabler) zapcore.Core {
+	core := zapcore.NewCore(encoder.Clone(), zapcore.Lock(zapcore.AddSync(out.out)), level)
+	if out.errOut == nil {
+		return core
+	}
+
+	return &splitCore{
+		Core: core,
+		high: zapcore.NewCore(encoder.Clone(), zapcore.Lock(zapcore.AddSync(out.errOut)), level),
+	}
+}
+
+func (l *ZapLogger) toOutputFormat(cfg *Config) (zapcore.Encoder, error) {
+	encCfg := zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "level",
+		MessageKey:     "msg",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    encodeZapLevel,
+		EncodeDuration: zapcore.StringDurationEncoder,
+	}
+
+	if cfg.FieldKeyMsg != "" {
+		encCfg.MessageKey = cfg.FieldKeyMsg
+	}
+
+	if cfg.FieldKeyLevel != "" {
+		encCfg.LevelKey = cfg.FieldKeyLevel
+	}
+
+	if cfg.FieldKeyTime != "" {
+		encCfg.TimeKey = cfg.FieldKeyTime
+	}
+
+	if cfg.DisableTimestamp {
+		encCfg.TimeKey = ""
+	}
+
+	switch cfg.OutFormat {
+	case "json":
+		encCfg.EncodeTime = zapcore.RFC3339TimeEncoder
+		if cfg.TimeFormat != "" {
+			encCfg.EncodeTime = zapcore.TimeEncoderOfLayout(cfg.TimeFormat)
+		}
+
+		if cfg.EpochTime {
+			millis := cfg.EpochMillis
+			encCfg.EncodeTime = func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+				enc.AppendInt64(epochValue(t, millis))
+			}
+		}
+		return zapcore.NewJSONEncoder(encCfg), nil
+	case "text":
+		timeFormat := cfg.TimeFormat
+		if timeFormat == "" {
+			timeFormat = "2006-01-02 15:04:05"
+		}
+		encCfg.EncodeTime = zapcore.TimeEncoderOfLayout(timeFormat)
+		return zapcore.NewConsoleEncoder(encCfg), nil
+	default:
+		return nil, fmt.Errorf(
+			"unknown formatter %s. Legal: json | text",
+			cfg.OutFormat,
+		)
+	}
+}
+
+// zapLevels maps the canonical level names to the zap levels
+var zapLevels = map[string]zapcore.Level{
+	levelTrace: zapTraceLevel,
+	levelDebug: zapcore.DebugLevel,
+	levelInfo:  zapcore.InfoLevel,
+	levelWarn:  zapcore.WarnLevel,
+	levelError: zapcore.ErrorLevel,
+	levelFatal: zapcore.FatalLevel,
+}
+
+func (l *ZapLogger) toLogLevel(name string) (zapcore.Level, error) {
+	name, err := parseLevel(name)
+	if err != nil {
+		return 0, err
+	}
+	return zapLevels[name], nil
+}
+
+// zapLevelName returns the canonical name of the given zap level
+func zapLevelName(level zapcore.Level) string {
+	if level == zapTraceLevel {
+		return levelTrace
+	}
+	return level.String()
+}
+
+// encodeZapLevel encodes levels by their canonical name
+func encodeZapLevel(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString(zapLevelName(level))
+}
+
+// splitCore is a zap core writing entries at error level and above to
+// a second core, used to split the output between stdout and stderr
+type splitCore struct {
+	zapcore.Core
+	high zapcore.Core
+}
+
+// With adds the fields to both cores
+func (c *splitCore) With(fields []zap.Field) zapcore.Core {
+	return &splitCore{
+		Core: c.Core.With(fields),
+		high: c.high.With(fields),
+	}
+}
+
+// Check adds this core to the checked entry if the entry is enabled
+func (c *splitCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+// Write writes the entry to the core for its level
+func (c *splitCore) Write(entry zapcore.Entry, fields []zap.Field) error {
+	if entry.Level >= zapcore.ErrorLevel {
+		return c.high.Write(entry, fields)
+	}
+	return c.Core.Write(entry, fields)
+}
+
+// Sync flushes both cores
+func (c *splitCore) Sync() error {
+	err := c.Core.Sync()
+	if highErr := c.high.Sync(); err == nil {
+		err = highErr
+	}
+	return err
+}
+
+// ------------------------------------------------------------------
+
+// zapFields converts the slice of Fields into zap fields, sorted by
+// name. As with mapify, the last of any fields sharing a name wins.
+func zapFields(fields []Field) []zap.Field {
+	data := mapify(fields...)
+
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	zfields := make([]zap.Field, len(names))
+	for i, name := range names {
+		zfields[i] = zap.Any(name, data[name])
+	}
+	releaseMap(data)
+	return zfields
+}